@@ -0,0 +1,87 @@
+package lexorank
+
+import (
+	"fmt"
+	"math"
+)
+
+var (
+	ErrOverflow       = fmt.Errorf("key arithmetic overflow")
+	ErrBucketMismatch = fmt.Errorf("keys are in different buckets")
+)
+
+// Key arithmetic treats every rank as a fixed-width fraction of the key space
+// at the full precision of 6 characters, so "0|U" and "0|U00000" are the same
+// value and a distance of 1 is always one step of the last character. Results
+// are trimmed of trailing minimum characters and never carry a site suffix.
+
+// Add returns the key distance steps after k. ErrOverflow is returned if the
+// result would fall outside of the key space.
+func (k Key) Add(distance int64) (Key, error) {
+	p := k.position()
+	if distance > 0 && p > int64(maxValue)-1-distance || distance < 0 && distance < -p {
+		return Key{}, fmt.Errorf("%w: %s + %d", ErrOverflow, k, distance)
+	}
+	return keyAtPosition(k.bucket, p+distance), nil
+}
+
+// Sub returns the key distance steps before k.
+func (k Key) Sub(distance int64) (Key, error) {
+	if distance == math.MinInt64 {
+		return Key{}, fmt.Errorf("%w: %s - %d", ErrOverflow, k, distance)
+	}
+	return k.Add(-distance)
+}
+
+// Distance returns the number of steps from k to other, which is negative if
+// other is before k.
+func (k Key) Distance(other Key) (int64, error) {
+	if k.bucket != other.bucket {
+		return 0, ErrBucketMismatch
+	}
+	return other.position() - k.position(), nil
+}
+
+// Mid returns the key halfway between k and other, rounded down. If the keys are
+// adjacent or equal, there's nothing between them and ErrRebalance is returned.
+func (k Key) Mid(other Key) (Key, error) {
+	d, err := k.Distance(other)
+	if err != nil {
+		return Key{}, err
+	}
+	if d > -2 && d < 2 {
+		return Key{}, ErrRebalance
+	}
+	lo := min(k.position(), other.position())
+	return keyAtPosition(k.bucket, lo+max(d, -d)/2), nil
+}
+
+// Scale returns the key at f times the position of k, such as 0.5 for the key
+// halfway between the bottom of the bucket and k.
+func (k Key) Scale(f float64) (Key, error) {
+	p := math.Round(float64(k.position()) * f)
+	if math.IsNaN(p) || p < 0 || p >= float64(maxValue) {
+		return Key{}, fmt.Errorf("%w: %s * %g", ErrOverflow, k, f)
+	}
+	return keyAtPosition(k.bucket, int64(p)), nil
+}
+
+// Cmp compares the values of two keys, their bucket first and then their rank
+// as a fixed-width number. Unlike Compare, trailing minimum characters and site
+// suffixes make no difference, so "0|U" and "0|U00000/1" are equal.
+func (k Key) Cmp(other Key) int {
+	switch {
+	case k.bucket < other.bucket:
+		return -1
+	case k.bucket > other.bucket:
+		return 1
+	}
+
+	switch p, q := k.position(), other.position(); {
+	case p < q:
+		return -1
+	case p > q:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+package lexorank
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey_AddSub(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	short, err := mustKey("0|U").Add(1)
+	r.NoError(err)
+	long, err := mustKey("0|U00000").Add(1)
+	r.NoError(err)
+	a.Equal("0|U00001", short.String())
+	a.Equal(short, long, "short ranks are padded rather than treated as smaller numbers")
+
+	k, err := mustKey("0|U").Add(75 * 75 * 75 * 75)
+	r.NoError(err)
+	a.Equal("0|U1", k.String(), "trailing zeros are trimmed")
+
+	k, err = k.Sub(75 * 75 * 75 * 75)
+	r.NoError(err)
+	a.Equal("0|U", k.String())
+
+	k, err = mustKey("2|a/1").Sub(1)
+	r.NoError(err)
+	a.Equal("2|`zzzzz", k.String(), "bucket is kept and suffix is dropped")
+
+	_, err = TopOf(0).Add(1)
+	a.ErrorIs(err, ErrOverflow)
+	_, err = Bottom.Sub(1)
+	a.ErrorIs(err, ErrOverflow)
+	_, err = Bottom.Add(math.MaxInt64)
+	a.ErrorIs(err, ErrOverflow)
+	_, err = Middle.Sub(math.MinInt64)
+	a.ErrorIs(err, ErrOverflow)
+	_, err = Bottom.Add(math.MinInt64)
+	a.ErrorIs(err, ErrOverflow)
+	_, err = Middle.Add(math.MinInt64)
+	a.ErrorIs(err, ErrOverflow)
+
+	k, err = Bottom.Add(int64(maxValue) - 1)
+	r.NoError(err)
+	a.Equal(Top, k)
+}
+
+func TestKey_DistanceMid(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	d, err := mustKey("0|a").Distance(mustKey("0|a00010"))
+	r.NoError(err)
+	a.Equal(int64(75), d)
+
+	d, err = mustKey("0|b").Distance(mustKey("0|a"))
+	r.NoError(err)
+	a.Equal(int64(-75*75*75*75*75), d)
+
+	_, err = mustKey("0|a").Distance(mustKey("1|a"))
+	a.ErrorIs(err, ErrBucketMismatch)
+
+	m, err := mustKey("0|a").Mid(mustKey("0|b"))
+	r.NoError(err)
+	a.Equal("0|aUUUUU", m.String())
+
+	m, err = mustKey("0|b").Mid(mustKey("0|a"))
+	r.NoError(err)
+	a.Equal("0|aUUUUU", m.String(), "order of the arguments does not matter")
+
+	m, err = mustKey("0|a").Mid(mustKey("0|a00002"))
+	r.NoError(err)
+	a.Equal("0|a00001", m.String())
+
+	_, err = mustKey("0|a").Mid(mustKey("0|a00001"))
+	a.ErrorIs(err, ErrRebalance)
+	_, err = mustKey("0|a").Mid(mustKey("2|a"))
+	a.ErrorIs(err, ErrBucketMismatch)
+}
+
+func TestKey_ScaleCmp(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	k, err := Top.Scale(0.5)
+	r.NoError(err)
+	a.Equal(0, k.Cmp(KeyAt(0, 0.5)))
+
+	k, err = mustKey("1|U").Scale(0)
+	r.NoError(err)
+	a.Equal("1|0", k.String())
+
+	_, err = Top.Scale(2)
+	a.ErrorIs(err, ErrOverflow)
+	_, err = Top.Scale(-1)
+	a.ErrorIs(err, ErrOverflow)
+	_, err = Top.Scale(math.NaN())
+	a.ErrorIs(err, ErrOverflow)
+
+	a.Equal(0, mustKey("0|U").Cmp(mustKey("0|U00000/1")))
+	a.Equal(-1, mustKey("0|U").Cmp(mustKey("0|U00001")))
+	a.Equal(1, mustKey("1|0").Cmp(mustKey("0|z")))
+	a.NotEqual(0, mustKey("0|U").Compare(mustKey("0|U00000")))
+}
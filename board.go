@@ -0,0 +1,91 @@
+package lexorank
+
+import (
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrGroupNotFound = fmt.Errorf("group not found")
+	ErrItemNotFound  = fmt.Errorf("item not found")
+)
+
+// Board holds several ReorderableLists keyed by a group identifier, such as the
+// columns of a kanban board. Each group is its own key space and is rebalanced
+// independently of the others.
+//
+// Items are located by identity, so Reorderable implementations should be
+// comparable, which is the case for the usual pointer-to-struct receivers.
+type Board[G comparable] struct {
+	groups map[G]ReorderableList
+}
+
+func NewBoard[G comparable]() *Board[G] {
+	return &Board[G]{groups: map[G]ReorderableList{}}
+}
+
+// Set adds or replaces a group. The board takes ownership of the list, it's
+// assumed to be already ordered just like any other ReorderableList.
+func (b *Board[G]) Set(group G, list ReorderableList) {
+	b.groups[group] = list
+}
+
+// List returns the current items of a group.
+func (b *Board[G]) List(group G) (ReorderableList, bool) {
+	l, ok := b.groups[group]
+	return l, ok
+}
+
+// Transfer removes an item from one group and inserts it into another at the
+// given position. The destination group may be rebalanced to make room for the
+// item, the source group never is since removing an item frees up space.
+//
+// The returned change sets are keyed by group and always include the moved item
+// under the destination group, so the move can be persisted in one transaction
+// along with any neighbours that were rebalanced. Transferring within the same
+// group is a plain move.
+func (b *Board[G]) Transfer(item Reorderable, from, to G, position uint) (map[G]Changes, error) {
+	src, ok := b.groups[from]
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	dst, ok := b.groups[to]
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+
+	index := slices.IndexFunc(src, func(r Reorderable) bool { return r == item })
+	if index == -1 {
+		return nil, ErrItemNotFound
+	}
+
+	size := len(dst)
+	if from == to {
+		size--
+	}
+	if position > uint(size) {
+		return nil, ErrOutOfBounds
+	}
+
+	src = slices.Delete(src, index, index+1)
+	b.groups[from] = src
+	if from == to {
+		dst = src
+	}
+
+	before := dst.keys()
+
+	k, err := dst.InsertIn(item.GetKey().bucket, position)
+	if err != nil {
+		b.groups[from] = slices.Insert(src, index, item)
+		return nil, err
+	}
+
+	changes := dst.changes(before)
+	changes = append(changes, Change{Item: item, Old: item.GetKey(), New: *k})
+	item.SetKey(*k)
+
+	b.groups[to] = slices.Insert(dst, int(position), item)
+
+	return map[G]Changes{to: changes}, nil
+}
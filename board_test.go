@@ -0,0 +1,114 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoard_Transfer(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	card := item(2, "1|c")
+
+	b := NewBoard[string]()
+	b.Set("todo", ReorderableList{item(0, "1|a"), item(1, "1|b"), card})
+	b.Set("done", ReorderableList{item(3, "1|a"), item(4, "1|b")})
+
+	changes, err := b.Transfer(card, "todo", "done", 1)
+	r.NoError(err)
+
+	todo, _ := b.List("todo")
+	done, _ := b.List("done")
+
+	a.Equal([]int{0, 1}, ids(todo))
+	a.Equal([]int{3, 2, 4}, ids(done))
+	a.True(done.IsSorted())
+
+	r.Len(changes, 1)
+	r.Len(changes["done"], 1)
+	a.Equal(card, changes["done"][0].Item)
+	a.Equal("1|c", changes["done"][0].Old.String())
+	a.Equal(card.GetKey(), changes["done"][0].New)
+}
+
+func TestBoard_Transfer_Rebalance(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	card := item(9, "1|U")
+
+	b := NewBoard[int]()
+	b.Set(1, ReorderableList{card})
+	b.Set(2, ReorderableList{item(0, "1|aaaaaa"), item(1, "1|aaaaab"), item(2, "1|aaaaac")})
+
+	changes, err := b.Transfer(card, 1, 2, 1)
+	r.NoError(err)
+
+	dst, _ := b.List(2)
+	a.Equal([]int{0, 9, 1, 2}, ids(dst))
+	a.True(dst.IsSorted())
+	a.Greater(len(changes[2]), 1, "neighbours were rebalanced")
+}
+
+func TestBoard_Transfer_SameGroup(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	card := item(0, "1|a")
+
+	b := NewBoard[string]()
+	b.Set("todo", ReorderableList{card, item(1, "1|b"), item(2, "1|c")})
+
+	_, err := b.Transfer(card, "todo", "todo", 2)
+	r.NoError(err)
+
+	todo, _ := b.List("todo")
+	a.Equal([]int{1, 2, 0}, ids(todo))
+	a.True(todo.IsSorted())
+}
+
+func TestBoard_Transfer_Errors(t *testing.T) {
+	a := assert.New(t)
+
+	card := item(0, "1|a")
+	b := NewBoard[string]()
+	b.Set("todo", ReorderableList{card})
+	b.Set("done", ReorderableList{})
+
+	_, err := b.Transfer(card, "todo", "nope", 0)
+	a.Equal(ErrGroupNotFound, err)
+
+	_, err = b.Transfer(item(5, "1|a"), "todo", "done", 0)
+	a.Equal(ErrItemNotFound, err)
+
+	_, err = b.Transfer(card, "todo", "done", 1)
+	a.Equal(ErrOutOfBounds, err)
+
+	todo, _ := b.List("todo")
+	a.Len(todo, 1, "failed transfers leave the source untouched")
+}
+
+func TestBoard_Transfer_EmptyGroup(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	card := item(0, "1|a")
+
+	b := NewBoard[string]()
+	b.Set("todo", ReorderableList{card, item(1, "1|b")})
+	b.Set("done", ReorderableList{})
+
+	_, err := b.Transfer(card, "todo", "done", 0)
+	r.NoError(err)
+	a.Equal(MiddleOf(1), card.GetKey(), "the first item leaves room either side")
+
+	next := item(1, "1|b")
+	b.Set("todo", ReorderableList{next})
+	changes, err := b.Transfer(next, "todo", "done", 1)
+	r.NoError(err)
+	a.Len(changes["done"], 1, "appending after it doesn't rewrite it")
+	a.Equal(MiddleOf(1), card.GetKey())
+}
@@ -0,0 +1,305 @@
+// Command lexorank inspects and generates lexorank keys.
+//
+//	lexorank between 0|a 0|b
+//	lexorank after 0|a 10
+//	lexorank before 0|a 10
+//	lexorank parse 0|aU
+//	lexorank spread 5
+//	lexorank validate < keys.txt
+//	lexorank normalise -strategy minimal rows.csv > changed.csv
+//
+// Every subcommand accepts -json to print its result as JSON for scripting.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Southclaws/lexorank"
+)
+
+var errProblems = errors.New("problems found")
+
+type command struct {
+	usage string
+	args  int // -1 for an optional file
+	flags func(fs *flag.FlagSet, c *env)
+	run   func(c *env, args []string) error
+}
+
+type env struct {
+	stdin     io.Reader
+	stdout    io.Writer
+	json      bool
+	bucket    uint
+	normalise normaliseOptions
+}
+
+var commands = map[string]command{
+	"between":   {"between A B", 2, nil, between},
+	"after":     {"after K N", 2, nil, after},
+	"before":    {"before K N", 2, nil, before},
+	"parse":     {"parse K", 1, nil, parse},
+	"spread":    {"spread [-bucket B] N", 1, spreadFlags, spread},
+	"validate":  {"validate < keys", 0, nil, validate},
+	"normalise": {"normalise [flags] [file]", -1, normaliseFlags, normalise},
+}
+
+var order = []string{"between", "after", "before", "parse", "spread", "validate", "normalise"}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "lexorank: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	c := &env{stdin: stdin, stdout: stdout}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: lexorank %s [-json]\n", cmd.usage)
+		fs.PrintDefaults()
+	}
+	fs.BoolVar(&c.json, "json", false, "print the result as JSON")
+	if cmd.flags != nil {
+		cmd.flags(fs, c)
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if cmd.args >= 0 && fs.NArg() != cmd.args || cmd.args < 0 && fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	if err := cmd.run(c, fs.Args()); err != nil {
+		if !errors.Is(err, errProblems) {
+			fmt.Fprintf(stderr, "lexorank: %v\n", err)
+		}
+		return 1
+	}
+
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: lexorank <command> [-json] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, name := range order {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+}
+
+// print writes v as JSON or the given lines as plain text.
+func (c *env) print(v any, lines ...string) error {
+	if c.json {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(c.stdout, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func between(c *env, args []string) error {
+	a, err := lexorank.ParseKey(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := lexorank.ParseKey(args[1])
+	if err != nil {
+		return err
+	}
+
+	k, ok := a.Between(*b)
+	if !ok || a.Compare(*k) == 0 || b.Compare(*k) == 0 {
+		return fmt.Errorf("no room between %s and %s, %w", a, b, lexorank.ErrRebalance)
+	}
+
+	return c.print(map[string]string{"key": k.String()}, k.String())
+}
+
+func after(c *env, args []string) error {
+	return step(c, args, (*lexorank.Key).After)
+}
+
+func before(c *env, args []string) error {
+	return step(c, args, (*lexorank.Key).Before)
+}
+
+func step(c *env, args []string, fn func(*lexorank.Key, int64) (*lexorank.Key, bool)) error {
+	k, err := lexorank.ParseKey(args[0])
+	if err != nil {
+		return err
+	}
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	next, ok := fn(k, n)
+	if !ok {
+		return fmt.Errorf("%s moved by %d is outside of the key space", k, n)
+	}
+
+	return c.print(map[string]string{"key": next.String()}, next.String())
+}
+
+type parsed struct {
+	Key      string `json:"key"`
+	Bucket   uint8  `json:"bucket"`
+	Rank     string `json:"rank"`
+	Suffix   string `json:"suffix,omitempty"`
+	Position int64  `json:"position"`
+	Uint64   uint64 `json:"uint64"`
+	Below    int64  `json:"headroom_below"`
+	Above    int64  `json:"headroom_above"`
+}
+
+func parse(c *env, args []string) error {
+	k, err := lexorank.ParseKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	p := parsed{Key: k.String(), Bucket: k.Bucket(), Suffix: k.Suffix(), Uint64: k.Uint64()}
+
+	_, rank, _ := strings.Cut(k.String(), "|")
+	p.Rank, _, _ = strings.Cut(rank, string(lexorank.SuffixSeparator))
+
+	p.Position, _ = lexorank.BottomOf(p.Bucket).Distance(*k)
+	p.Below = p.Position
+	p.Above, _ = k.Distance(lexorank.TopOf(p.Bucket))
+
+	return c.print(p,
+		"key:       "+p.Key,
+		"bucket:    "+strconv.Itoa(int(p.Bucket)),
+		"rank:      "+p.Rank,
+		"suffix:    "+p.Suffix,
+		"position:  "+strconv.FormatInt(p.Position, 10),
+		"uint64:    "+strconv.FormatUint(p.Uint64, 10),
+		"headroom:  "+strconv.FormatInt(p.Below, 10)+" below, "+strconv.FormatInt(p.Above, 10)+" above",
+	)
+}
+
+func spreadFlags(fs *flag.FlagSet, c *env) {
+	fs.UintVar(&c.bucket, "bucket", 0, "bucket of the generated keys")
+}
+
+func spread(c *env, args []string) error {
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return fmt.Errorf("cannot spread %d keys", n)
+	}
+	if c.bucket > 2 {
+		return fmt.Errorf("bucket must be 0, 1 or 2")
+	}
+
+	keys := make([]string, 0, n)
+	for _, k := range lexorank.EvenlySpaced(uint8(c.bucket), n) {
+		keys = append(keys, k.String())
+	}
+
+	return c.print(keys, keys...)
+}
+
+type problem struct {
+	Line    int    `json:"line"`
+	Kind    string `json:"kind"`
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+type report struct {
+	Keys     int       `json:"keys"`
+	Problems []problem `json:"problems"`
+}
+
+// validate reads one key per line and reports keys that don't parse,
+// duplicates, keys that sort before the line above them and adjacent keys with
+// no room left between them.
+func validate(c *env, args []string) error {
+	r := report{Problems: []problem{}}
+
+	seen := map[string]int{}
+	var prev *lexorank.Key
+
+	sc := bufio.NewScanner(c.stdin)
+	line := 0
+	for sc.Scan() {
+		line++
+		s := strings.TrimSpace(sc.Text())
+		if s == "" {
+			continue
+		}
+		r.Keys++
+
+		k, err := lexorank.ParseKey(s)
+		if err != nil {
+			r.Problems = append(r.Problems, problem{line, "invalid", s, err.Error()})
+			continue
+		}
+
+		if first, ok := seen[k.String()]; ok {
+			r.Problems = append(r.Problems, problem{line, "duplicate", s, fmt.Sprintf("same as line %d", first)})
+		} else {
+			seen[k.String()] = line
+		}
+
+		if prev != nil {
+			switch cmp := prev.Compare(*k); {
+			case cmp > 0:
+				r.Problems = append(r.Problems, problem{line, "inversion", s, fmt.Sprintf("sorts before %s on the line above", prev)})
+			case cmp < 0:
+				if b, ok := prev.Between(*k); !ok || b.Compare(*prev) == 0 || b.Compare(*k) == 0 {
+					r.Problems = append(r.Problems, problem{line, "dead-end", s, fmt.Sprintf("no room between %s and %s", prev, s)})
+				}
+			}
+		}
+		prev = k
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	lines := []string{fmt.Sprintf("%d keys, %d problems", r.Keys, len(r.Problems))}
+	for _, p := range r.Problems {
+		lines = append(lines, fmt.Sprintf("line %d: %s: %s: %s", p.Line, p.Kind, p.Key, p.Message))
+	}
+	if err := c.print(r, lines...); err != nil {
+		return err
+	}
+
+	if len(r.Problems) > 0 {
+		return errProblems
+	}
+	return nil
+}
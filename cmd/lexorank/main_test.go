@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exec(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestKeyCommands(t *testing.T) {
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		args []string
+		out  string
+	}{
+		{[]string{"between", "0|a", "0|b"}, "0|aU\n"},
+		{[]string{"after", "0|0", "10"}, "0|00000:\n"},
+		{[]string{"before", "0|zzzzzz", "10"}, "0|zzzzzp\n"},
+		{[]string{"spread", "-bucket", "2", "3"}, "2|I\n2|UUUUUU\n2|b\n"},
+		{[]string{"between", "-json", "0|a", "0|b"}, "{\n  \"key\": \"0|aU\"\n}\n"},
+	} {
+		code, out, stderr := exec("", tc.args...)
+		a.Equal(0, code, stderr)
+		a.Equal(tc.out, out, tc.args)
+	}
+}
+
+func TestKeyCommands_Errors(t *testing.T) {
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		args []string
+		code int
+		err  string
+	}{
+		{nil, 2, "usage"},
+		{[]string{"nope"}, 2, "unknown command"},
+		{[]string{"between", "0|a"}, 2, "usage: lexorank between A B"},
+		{[]string{"between", "0|a", "0|a00001"}, 1, "no room between"},
+		{[]string{"after", "0|z", "x"}, 1, "invalid syntax"},
+		{[]string{"before", "0|0", "1"}, 1, "outside of the key space"},
+		{[]string{"parse", "0"}, 1, "lexorank:"},
+		{[]string{"spread", "-bucket", "3", "1"}, 1, "bucket"},
+	} {
+		code, _, stderr := exec("", tc.args...)
+		a.Equal(tc.code, code, tc.args)
+		a.Contains(stderr, tc.err, tc.args)
+	}
+}
+
+func TestParse(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	code, out, _ := exec("", "parse", "-json", "1|aU/x")
+	r.Equal(0, code)
+
+	var p parsed
+	r.NoError(json.Unmarshal([]byte(out), &p))
+	a.Equal(uint8(1), p.Bucket)
+	a.Equal("aU", p.Rank)
+	a.Equal("x", p.Suffix)
+	a.Equal(p.Position, p.Below)
+	a.Equal(int64(75*75*75*75*75*75-1), p.Below+p.Above)
+}
+
+func TestValidate(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	code, out, _ := exec("0|a\n0|b\n\n0|c\n", "validate")
+	a.Equal(0, code)
+	a.Equal("3 keys, 0 problems\n", out)
+
+	input := strings.Join([]string{"0|a", "0|b", "0|b", "0|a", "0|a00001", "bad"}, "\n")
+	code, out, _ = exec(input, "validate", "-json")
+	a.Equal(1, code)
+
+	var rep report
+	r.NoError(json.Unmarshal([]byte(out), &rep))
+	a.Equal(6, rep.Keys)
+
+	var kinds []string
+	for _, p := range rep.Problems {
+		kinds = append(kinds, p.Kind)
+	}
+	a.Equal([]string{"duplicate", "duplicate", "inversion", "dead-end", "invalid"}, kinds)
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Southclaws/lexorank"
+)
+
+type normaliseOptions struct {
+	format   string
+	id       string
+	rank     string
+	scope    string
+	strategy string
+	dryRun   bool
+	sorted   bool
+}
+
+func normaliseFlags(fs *flag.FlagSet, c *env) {
+	o := &c.normalise
+	fs.StringVar(&o.format, "format", "", "csv or jsonl, guessed from the file extension by default")
+	fs.StringVar(&o.id, "id", "id", "name of the ID column")
+	fs.StringVar(&o.rank, "rank", "rank", "name of the rank column")
+	fs.StringVar(&o.scope, "scope", "group", "name of the column or field rows are grouped by, which every row must have, empty for a single group")
+	fs.StringVar(&o.strategy, "strategy", "minimal", "full, minimal or shortest")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "print statistics instead of the changed rows")
+	fs.BoolVar(&o.sorted, "sorted", false, "the input is already grouped by scope, so only one group is held in memory at a time")
+}
+
+// row is a single record of the input, it implements lexorank.Reorderable so a
+// group can be handed to the library as a list.
+type row struct {
+	id    string
+	scope string
+	rank  string
+	key   lexorank.Key
+	valid bool
+
+	record []string                   // csv
+	object map[string]json.RawMessage // jsonl
+}
+
+func (r *row) GetKey() lexorank.Key  { return r.key }
+func (r *row) SetKey(k lexorank.Key) { r.key = k }
+
+type rowReader interface {
+	Read() (*row, error)
+}
+
+type rowWriter interface {
+	Write(r *row, rank string) error
+	Flush() error
+}
+
+type stats struct {
+	Groups  int `json:"groups"`
+	Rows    int `json:"rows"`
+	Changed int `json:"changed"`
+	Invalid int `json:"invalid"`
+}
+
+// normalise reads rows from a CSV or JSON Lines file, orders each group by its
+// current rank with the ID as a tiebreak and writes out the rows whose rank was
+// changed by the chosen strategy:
+//
+//   - full: every group is given evenly spaced keys.
+//   - minimal: only rows that are out of order, duplicated or invalid change.
+//   - shortest: every group is given evenly spaced keys of the fewest
+//     characters that fit the group.
+func normalise(c *env, args []string) error {
+	o := c.normalise
+
+	if !slices.Contains([]string{"full", "minimal", "shortest"}, o.strategy) {
+		return fmt.Errorf("unknown strategy %q", o.strategy)
+	}
+
+	in := c.stdin
+	if len(args) == 1 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+
+		if o.format == "" {
+			o.format = strings.TrimPrefix(filepath.Ext(args[0]), ".")
+		}
+	}
+
+	var rd rowReader
+	var w rowWriter
+	out := bufio.NewWriter(c.stdout)
+
+	switch o.format {
+	case "csv", "":
+		cr, err := newCSVReader(in, o)
+		if err != nil {
+			return err
+		}
+		rd = cr
+		w = &csvWriter{w: csv.NewWriter(out), header: cr.header, rank: cr.rank}
+	case "jsonl", "ndjson":
+		sc := bufio.NewScanner(in)
+		sc.Buffer(nil, 1<<20)
+		rd = &jsonlReader{sc: sc, o: o}
+		w = &jsonlWriter{w: out, rank: o.rank}
+	default:
+		return fmt.Errorf("unknown format %q", o.format)
+	}
+
+	var st stats
+
+	flush := func(group []*row) error {
+		if len(group) == 0 {
+			return nil
+		}
+		st.Groups++
+
+		changed, err := apply(group, o.strategy)
+		if err != nil {
+			return fmt.Errorf("group %q: %w", group[0].scope, err)
+		}
+
+		for _, r := range changed {
+			st.Changed++
+			if !o.dryRun {
+				if err := w.Write(r, r.key.String()); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	groups := map[string][]*row{}
+	var seen []string
+	var current []*row
+	done := map[string]bool{}
+
+	for {
+		r, err := rd.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		st.Rows++
+		if !r.valid {
+			st.Invalid++
+		}
+
+		if !o.sorted {
+			if _, ok := groups[r.scope]; !ok {
+				seen = append(seen, r.scope)
+			}
+			groups[r.scope] = append(groups[r.scope], r)
+			continue
+		}
+
+		if len(current) > 0 && current[0].scope != r.scope {
+			done[current[0].scope] = true
+			if err := flush(current); err != nil {
+				return err
+			}
+			current = nil
+		}
+		if done[r.scope] {
+			return fmt.Errorf("input is not grouped by scope, %q appears again after other groups", r.scope)
+		}
+		current = append(current, r)
+	}
+
+	if o.sorted {
+		if err := flush(current); err != nil {
+			return err
+		}
+	}
+	for _, s := range seen {
+		if err := flush(groups[s]); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+
+	if o.dryRun {
+		return c.print(st,
+			fmt.Sprintf("groups:  %d", st.Groups),
+			fmt.Sprintf("rows:    %d", st.Rows),
+			fmt.Sprintf("changed: %d", st.Changed),
+			fmt.Sprintf("invalid: %d", st.Invalid),
+		)
+	}
+
+	return nil
+}
+
+// apply orders a group and gives it new keys with the strategy, returning the
+// rows whose rank changed in their new order.
+func apply(group []*row, strategy string) ([]*row, error) {
+	slices.SortStableFunc(group, func(a, b *row) int {
+		switch {
+		case a.valid && !b.valid:
+			return -1
+		case !a.valid && b.valid:
+			return 1
+		case a.valid && b.valid:
+			if c := a.key.Compare(b.key); c != 0 {
+				return c
+			}
+		}
+		return compareIDs(a.id, b.id)
+	})
+
+	var bucket uint8
+	if group[0].valid {
+		bucket = group[0].key.Bucket()
+	}
+
+	l := make(lexorank.ReorderableList, len(group))
+	for i, r := range group {
+		l[i] = r
+	}
+
+	switch strategy {
+	case "full":
+		for i, k := range lexorank.EvenlySpaced(bucket, len(l)) {
+			l[i].SetKey(k)
+		}
+	case "minimal":
+		l.Repair()
+	case "shortest":
+		keys, err := shortest(bucket, len(l))
+		if err != nil {
+			return nil, err
+		}
+		for i, k := range keys {
+			l[i].SetKey(k)
+		}
+	}
+
+	var changed []*row
+	for _, r := range group {
+		if r.key.String() != r.rank {
+			changed = append(changed, r)
+		}
+	}
+	return changed, nil
+}
+
+// shortest returns n evenly spaced keys using as few rank characters as can
+// hold n distinct keys.
+func shortest(bucket uint8, n int) (lexorank.Keys, error) {
+	const base = 75
+
+	space, scale := int64(base), int64(base*base*base*base*base)
+	for space < int64(n+1) {
+		if scale == 1 {
+			return nil, fmt.Errorf("%d rows do not fit in the key space", n)
+		}
+		space *= base
+		scale /= base
+	}
+
+	keys := make(lexorank.Keys, n)
+	for i := range keys {
+		p := int64(i+1) * space / int64(n+1)
+
+		k, err := lexorank.BottomOf(bucket).Add(p * scale)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = k
+	}
+	return keys, nil
+}
+
+// compareIDs orders numeric IDs by value and anything else as text.
+func compareIDs(a, b string) int {
+	x, errx := strconv.ParseInt(a, 10, 64)
+	y, erry := strconv.ParseInt(b, 10, 64)
+	if errx == nil && erry == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+func newRow(id, scope, rank string) *row {
+	r := &row{id: id, scope: scope, rank: rank}
+	if k, err := lexorank.ParseKey(rank); err == nil {
+		r.key, r.valid = *k, true
+	}
+	return r
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+
+	id, rank, scope int
+}
+
+func newCSVReader(in io.Reader, o normaliseOptions) (*csvReader, error) {
+	r := csv.NewReader(in)
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	column := func(name string) (int, error) {
+		i := slices.Index(header, name)
+		if i == -1 {
+			return 0, fmt.Errorf("no %q column in header", name)
+		}
+		return i, nil
+	}
+
+	cr := &csvReader{r: r, header: header, scope: -1}
+	if cr.id, err = column(o.id); err != nil {
+		return nil, err
+	}
+	if cr.rank, err = column(o.rank); err != nil {
+		return nil, err
+	}
+	if o.scope != "" {
+		if cr.scope, err = column(o.scope); err != nil {
+			return nil, err
+		}
+	}
+
+	return cr, nil
+}
+
+func (cr *csvReader) Read() (*row, error) {
+	rec, err := cr.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	scope := ""
+	if cr.scope >= 0 {
+		scope = rec[cr.scope]
+	}
+
+	r := newRow(rec[cr.id], scope, rec[cr.rank])
+	r.record = rec
+	return r, nil
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	header  []string
+	rank    int
+	started bool
+}
+
+func (cw *csvWriter) Write(r *row, rank string) error {
+	if !cw.started {
+		cw.started = true
+		if err := cw.w.Write(cw.header); err != nil {
+			return err
+		}
+	}
+
+	rec := slices.Clone(r.record)
+	rec[cw.rank] = rank
+	return cw.w.Write(rec)
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type jsonlReader struct {
+	sc   *bufio.Scanner
+	o    normaliseOptions
+	line int
+}
+
+func (jr *jsonlReader) Read() (*row, error) {
+	for jr.sc.Scan() {
+		jr.line++
+
+		b := jr.sc.Bytes()
+		if len(strings.TrimSpace(string(b))) == 0 {
+			continue
+		}
+
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(b, &obj); err != nil {
+			return nil, fmt.Errorf("line %d: %w", jr.line, err)
+		}
+
+		id, ok := obj[jr.o.id]
+		if !ok {
+			return nil, fmt.Errorf("line %d: no %q field", jr.line, jr.o.id)
+		}
+
+		scope := ""
+		if jr.o.scope != "" {
+			s, ok := obj[jr.o.scope]
+			if !ok {
+				return nil, fmt.Errorf("line %d: no %q field", jr.line, jr.o.scope)
+			}
+			scope = text(s)
+		}
+
+		r := newRow(text(id), scope, text(obj[jr.o.rank]))
+		r.object = obj
+		return r, nil
+	}
+
+	if err := jr.sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// text returns a JSON string's contents, or the raw JSON of any other value so
+// numeric IDs are kept as written.
+func text(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+type jsonlWriter struct {
+	w    io.Writer
+	rank string
+}
+
+func (jw *jsonlWriter) Write(r *row, rank string) error {
+	obj := make(map[string]json.RawMessage, len(r.object))
+	for k, v := range r.object {
+		obj[k] = v
+	}
+
+	v, err := json.Marshal(rank)
+	if err != nil {
+		return err
+	}
+	obj[jw.rank] = v
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = jw.w.Write(append(b, '\n'))
+	return err
+}
+
+func (jw *jsonlWriter) Flush() error { return nil }
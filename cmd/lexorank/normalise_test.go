@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rowsCSV = `id,rank,group
+1,0|b,x
+2,0|a,x
+3,0|a,x
+4,,x
+5,0|a,y
+6,0|c,y
+`
+
+func TestNormalise_Strategies(t *testing.T) {
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		strategy string
+		out      string
+	}{
+		{"minimal", "id,rank,group\n2,0|HUUUUU,x\n4,0|nUUUUU,x\n"},
+		{"shortest", "id,rank,group\n2,0|?,x\n3,0|N,x\n1,0|],x\n4,0|l,x\n5,0|I,y\n6,0|b,y\n"},
+	} {
+		code, out, stderr := exec(rowsCSV, "normalise", "-strategy", tc.strategy)
+		a.Equal(0, code, stderr)
+		a.Equal(tc.out, out, tc.strategy)
+	}
+
+	code, out, _ := exec(rowsCSV, "normalise", "-strategy", "full")
+	a.Equal(0, code)
+	a.Len(strings.Split(strings.TrimSpace(out), "\n"), 7, "every row of both groups is rewritten")
+}
+
+func TestNormalise_DryRun(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	code, out, _ := exec(rowsCSV, "normalise", "-dry-run", "-json")
+	r.Equal(0, code)
+
+	var st stats
+	r.NoError(json.Unmarshal([]byte(out), &st))
+	a.Equal(stats{Groups: 2, Rows: 6, Changed: 2, Invalid: 1}, st)
+}
+
+func TestNormalise_JSONLFile(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "rows.jsonl")
+	r.NoError(os.WriteFile(path, []byte(strings.Join([]string{
+		`{"id": 10, "position": "0|b", "list": "x"}`,
+		`{"id": 9, "position": "0|b", "list": "x", "name": "keep me"}`,
+		``,
+		`{"id": 11, "position": "0|c", "list": "x"}`,
+	}, "\n")), 0o644))
+
+	code, out, stderr := exec("", "normalise", "-rank", "position", "-scope", "list", path)
+	r.Equal(0, code, stderr)
+
+	// The ID tiebreak puts 9 before 10, so 9 is moved in front of the other
+	// duplicate.
+	var obj map[string]any
+	r.NoError(json.Unmarshal([]byte(out), &obj))
+	a.Equal(float64(9), obj["id"])
+	a.Equal("keep me", obj["name"])
+	a.NotEqual("0|b", obj["position"])
+	a.Equal(1, strings.Count(out, "\n"))
+}
+
+func TestNormalise_Sorted(t *testing.T) {
+	a := assert.New(t)
+
+	code, out, _ := exec(rowsCSV, "normalise", "-sorted", "-strategy", "minimal")
+	a.Equal(0, code)
+	a.Equal("id,rank,group\n2,0|HUUUUU,x\n4,0|nUUUUU,x\n", out)
+
+	ungrouped := "id,rank,group\n1,0|a,x\n2,0|a,y\n3,0|a,x\n"
+	code, _, stderr := exec(ungrouped, "normalise", "-sorted")
+	a.Equal(1, code)
+	a.Contains(stderr, "not grouped by scope")
+}
+
+func TestNormalise_Errors(t *testing.T) {
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		stdin string
+		args  []string
+		err   string
+	}{
+		{rowsCSV, []string{"-strategy", "best"}, "unknown strategy"},
+		{rowsCSV, []string{"-format", "xml"}, "unknown format"},
+		{"id,rank\n1,0|a\n", nil, `no "group" column`},
+		{"", nil, "reading header"},
+		{"{\n", []string{"-format", "jsonl"}, "line 1"},
+		{`{"rank": "0|a"}`, []string{"-format", "jsonl"}, `no "id" field`},
+		{`{"id": 1, "rank": "0|a"}`, []string{"-format", "jsonl"}, `no "group" field`},
+	} {
+		code, _, stderr := exec(tc.stdin, append([]string{"normalise"}, tc.args...)...)
+		a.Equal(1, code, tc.args)
+		a.Contains(stderr, tc.err, tc.args)
+	}
+
+	code, out, _ := exec("id,rank\n1,0|a\n2,0|a\n", "normalise", "-scope", "")
+	a.Equal(0, code)
+	a.Equal("id,rank\n1,0|HUUUUU\n", out, "a single group when there's no scope")
+
+	code, out, _ = exec("{\"id\": 1, \"rank\": \"0|a\"}\n{\"id\": 2, \"rank\": \"0|a\"}\n", "normalise", "-format", "jsonl", "-scope", "")
+	a.Equal(0, code)
+	a.Equal(1, strings.Count(out, "\n"), "a single group when there's no scope")
+}
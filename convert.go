@@ -0,0 +1,68 @@
+package lexorank
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+var ErrInvalidPosition = fmt.Errorf("invalid position")
+
+// FromOrdinals converts a legacy integer position column into keys. The key at
+// each index is for the row with the position at the same index, the positions
+// don't have to be sorted and may have gaps. Rows with the same position keep
+// the order they were given in.
+//
+// The keys are evenly spaced and identical to those Normalise would give the
+// rows once sorted, so a migrated list is already normalised.
+func FromOrdinals(bucket uint8, positions []int64) Keys {
+	return fromOrder(bucket, len(positions), func(i, j int) bool {
+		return positions[i] < positions[j]
+	})
+}
+
+// FromFloats is FromOrdinals for floating point position columns. NaN has no
+// order so ErrInvalidPosition is returned if any position is NaN.
+func FromFloats(bucket uint8, positions []float64) (Keys, error) {
+	if i := slices.IndexFunc(positions, math.IsNaN); i != -1 {
+		return nil, fmt.Errorf("%w: NaN at index %d", ErrInvalidPosition, i)
+	}
+
+	return fromOrder(bucket, len(positions), func(i, j int) bool {
+		return positions[i] < positions[j]
+	}), nil
+}
+
+func fromOrder(bucket uint8, n int, less func(i, j int) bool) Keys {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return less(order[a], order[b]) })
+
+	keys := make(Keys, n)
+	for rank, i := range order {
+		keys[i] = normaliseKey(bucket, rank, n)
+	}
+	return keys
+}
+
+// ToOrdinals is the reverse of FromOrdinals, it returns the zero based position
+// of each item in key order, at the same index as the item. This can be used to
+// verify a migration against the original column or to roll it back.
+func ToOrdinals(l ReorderableList) []int64 {
+	order := make([]int, len(l))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return l[order[a]].GetKey().Compare(l[order[b]].GetKey()) < 0
+	})
+
+	ordinals := make([]int64, len(l))
+	for rank, i := range order {
+		ordinals[i] = int64(rank)
+	}
+	return ordinals
+}
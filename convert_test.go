@@ -0,0 +1,71 @@
+package lexorank
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromOrdinals(t *testing.T) {
+	a := assert.New(t)
+
+	// Gaps, duplicates and negative positions, in no particular order.
+	positions := []int64{30, 10, 30, -5, 1000, 10}
+
+	keys := FromOrdinals(1, positions)
+	a.Len(keys, len(positions))
+
+	l := make(ReorderableList, len(keys))
+	for i, k := range keys {
+		l[i] = &Item{ID: i, Rank: k}
+	}
+
+	// Sorted by key, rows follow their positions and duplicates keep their
+	// original order.
+	sorted := make(ReorderableList, len(l))
+	copy(sorted, l)
+	sort.Sort(sorted)
+	a.Equal([]int{3, 1, 5, 0, 2, 4}, ids(sorted))
+
+	want := largeList(len(keys))
+	want.Normalise()
+	a.Equal(want.keys(), sorted.keys(), "keys match a normalised list")
+
+	a.Equal([]int64{3, 1, 4, 0, 5, 2}, ToOrdinals(l))
+	a.Empty(FromOrdinals(0, nil))
+}
+
+func TestFromFloats(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	keys, err := FromFloats(0, []float64{0.5, math.Inf(-1), 0.25, 0.5, math.Inf(1)})
+	r.NoError(err)
+
+	l := make(ReorderableList, len(keys))
+	for i, k := range keys {
+		l[i] = &Item{ID: i, Rank: k}
+	}
+	a.Equal([]int64{2, 0, 1, 3, 4}, ToOrdinals(l))
+
+	_, err = FromFloats(0, []float64{1, math.NaN()})
+	a.ErrorIs(err, ErrInvalidPosition)
+}
+
+func TestToOrdinals_RoundTrip(t *testing.T) {
+	a := assert.New(t)
+
+	positions := []int64{7, 3, 9, 1}
+	keys := FromOrdinals(0, positions)
+
+	l := make(ReorderableList, len(keys))
+	for i, k := range keys {
+		l[i] = &Item{ID: i, Rank: k}
+	}
+
+	// Dense ordinals can be fed back in and produce the same keys.
+	a.Equal(keys, FromOrdinals(0, ToOrdinals(l)))
+}
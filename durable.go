@@ -0,0 +1,409 @@
+package lexorank
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrCorrupt = fmt.Errorf("durable set is corrupt")
+
+const (
+	durableLogName      = "wal.log"
+	durableSnapshotName = "snapshot.log"
+)
+
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs the log after every mutation, nothing that returned
+	// successfully is ever lost.
+	SyncAlways SyncPolicy = iota
+
+	// SyncBatch fsyncs the log after every SyncEvery mutations, a crash may lose
+	// the most recent mutations but never leaves the set half-written.
+	SyncBatch
+
+	// SyncNever leaves flushing to the operating system.
+	SyncNever
+)
+
+type DurableOptions struct {
+	Sync SyncPolicy
+
+	// SyncEvery is the number of mutations between fsyncs for SyncBatch.
+	SyncEvery int
+
+	// CompactEvery is the number of mutations after which the log is compacted
+	// into a snapshot automatically. Zero disables automatic compaction.
+	CompactEvery int
+}
+
+// DurableSet is an OrderedSet that persists every key change to a local append
+// only log, so ordered lists can live in-process without a database.
+//
+// Every mutation is written as a single line to the log before it's made
+// visible in memory. Periodically, the whole set is compacted into a snapshot
+// file and the log is truncated. On open, the snapshot is loaded and the log is
+// replayed on top of it, a partially written final line from a crash is
+// discarded. Any other record that can't be read fails the open with
+// ErrCorrupt. Values must be encodable with encoding/json.
+type DurableSet[T any] struct {
+	set  *OrderedSet[T]
+	dir  string
+	opts DurableOptions
+
+	// wmu serialises writers so the order of the log matches the order that
+	// mutations are applied in memory, readers only ever go through set.
+	wmu      sync.Mutex
+	log      *os.File
+	unsynced int
+	records  int
+}
+
+type durableWrite[T any] struct {
+	Key    Key  `json:"key"`
+	Value  T    `json:"value"`
+	Delete bool `json:"delete,omitempty"`
+}
+
+type durableRecord[T any] struct {
+	Writes []durableWrite[T] `json:"writes"`
+}
+
+// OpenDurableSet opens or creates a durable set stored in dir.
+func OpenDurableSet[T any](dir string, opts DurableOptions) (*DurableSet[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	d := &DurableSet[T]{set: NewOrderedSet[T](), dir: dir, opts: opts}
+
+	if err := d.recover(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *DurableSet[T]) Len() int                      { return d.set.Len() }
+func (d *DurableSet[T]) Get(k Key) (T, bool)           { return d.set.Get(k) }
+func (d *DurableSet[T]) At(i uint) (Key, T, bool)      { return d.set.At(i) }
+func (d *DurableSet[T]) Rank(k Key) (uint, bool)       { return d.set.Rank(k) }
+func (d *DurableSet[T]) Iter() *Iterator[T]            { return d.set.Iter() }
+func (d *DurableSet[T]) Range(lo, hi Key) *Iterator[T] { return d.set.Range(lo, hi) }
+
+// Insert adds a value with a specific key.
+func (d *DurableSet[T]) Insert(k Key, v T) error {
+	return d.mutate(func(root *treapNode[T]) (*treapNode[T], []setWrite[T], error) {
+		return root.insert(k, v)
+	})
+}
+
+// Delete removes the value with the given key.
+func (d *DurableSet[T]) Delete(k Key) (bool, error) {
+	found := false
+	err := d.mutate(func(root *treapNode[T]) (*treapNode[T], []setWrite[T], error) {
+		root, writes, ok := root.delete(k)
+		found = ok
+		return root, writes, nil
+	})
+	return found, err
+}
+
+// SetObserver reports the set's rebalances to o, tagged with the list ID.
+func (d *DurableSet[T]) SetObserver(list string, o Observer) {
+	d.set.SetObserver(list, o)
+}
+
+// InsertAt generates a key for a new value so that it's placed at position i.
+func (d *DurableSet[T]) InsertAt(i uint, v T) (Key, error) {
+	var k Key
+	err := d.mutate(func(root *treapNode[T]) (*treapNode[T], []setWrite[T], error) {
+		root, key, writes, err := root.insertAt(i, v, d.set.events())
+		k = key
+		return root, writes, err
+	})
+	return k, err
+}
+
+// Move takes the value at position from and places it at position to.
+func (d *DurableSet[T]) Move(from, to uint) (Key, error) {
+	var k Key
+	err := d.mutate(func(root *treapNode[T]) (*treapNode[T], []setWrite[T], error) {
+		root, key, writes, err := root.move(from, to, d.set.events())
+		k = key
+		return root, writes, err
+	})
+	return k, err
+}
+
+// Sync flushes the log to disk regardless of the sync policy.
+func (d *DurableSet[T]) Sync() error {
+	d.wmu.Lock()
+	defer d.wmu.Unlock()
+
+	d.unsynced = 0
+	return d.log.Sync()
+}
+
+// Close syncs and closes the log.
+func (d *DurableSet[T]) Close() error {
+	d.wmu.Lock()
+	defer d.wmu.Unlock()
+
+	if err := d.log.Sync(); err != nil {
+		return err
+	}
+	return d.log.Close()
+}
+
+// Compact writes the whole set to a new snapshot file and truncates the log.
+func (d *DurableSet[T]) Compact() error {
+	d.wmu.Lock()
+	defer d.wmu.Unlock()
+
+	return d.compact()
+}
+
+func (d *DurableSet[T]) mutate(fn func(*treapNode[T]) (*treapNode[T], []setWrite[T], error)) error {
+	d.wmu.Lock()
+	defer d.wmu.Unlock()
+
+	d.set.mu.RLock()
+	root := d.set.root
+	d.set.mu.RUnlock()
+
+	root, writes, err := fn(root)
+	if err != nil {
+		return err
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+
+	if err := d.append(writes); err != nil {
+		return err
+	}
+
+	d.set.mu.Lock()
+	d.set.root = root
+	d.set.mu.Unlock()
+
+	d.records++
+	if d.opts.CompactEvery > 0 && d.records >= d.opts.CompactEvery {
+		return d.compact()
+	}
+
+	return nil
+}
+
+func (d *DurableSet[T]) append(writes []setWrite[T]) error {
+	record := durableRecord[T]{Writes: make([]durableWrite[T], len(writes))}
+	for i, w := range writes {
+		record.Writes[i] = durableWrite[T]{Key: w.key, Value: w.value, Delete: w.delete}
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if _, err := d.log.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	d.unsynced++
+
+	switch d.opts.Sync {
+	case SyncAlways:
+		d.unsynced = 0
+		return d.log.Sync()
+	case SyncBatch:
+		if d.unsynced >= max(1, d.opts.SyncEvery) {
+			d.unsynced = 0
+			return d.log.Sync()
+		}
+	}
+
+	return nil
+}
+
+func (d *DurableSet[T]) compact() error {
+	tmp := filepath.Join(d.dir, durableSnapshotName+".tmp")
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	d.set.mu.RLock()
+	it := &Iterator[T]{root: d.set.root, end: d.set.root.len()}
+	d.set.mu.RUnlock()
+
+	for it.Next() {
+		if err := enc.Encode(durableWrite[T]{Key: it.Key(), Value: it.Value()}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, filepath.Join(d.dir, durableSnapshotName)); err != nil {
+		return err
+	}
+	syncDir(d.dir)
+
+	// Replaying the old log over the new snapshot is harmless, every write is
+	// idempotent, so a crash before the truncate loses nothing.
+	if err := d.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := d.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	d.records = 0
+	d.unsynced = 0
+
+	return d.log.Sync()
+}
+
+func (d *DurableSet[T]) recover() error {
+	root, err := d.loadSnapshot()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(d.dir, durableLogName)
+
+	log, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(log)
+	if err != nil {
+		log.Close()
+		return err
+	}
+
+	good := 0
+	for good < len(data) {
+		end := bytes.IndexByte(data[good:], '\n')
+		if end == -1 {
+			break // torn write from a crash
+		}
+
+		var record durableRecord[T]
+		if err := json.Unmarshal(data[good:good+end], &record); err != nil {
+			// Only the final line can be torn, a complete record that doesn't
+			// parse means the log was damaged some other way.
+			log.Close()
+			return fmt.Errorf("%w: log record %d: %v", ErrCorrupt, d.records+1, err)
+		}
+
+		root = replayWrites(root, record.Writes)
+		good += end + 1
+		d.records++
+	}
+
+	if good < len(data) {
+		if err := log.Truncate(int64(good)); err != nil {
+			log.Close()
+			return err
+		}
+	}
+	if _, err := log.Seek(int64(good), io.SeekStart); err != nil {
+		log.Close()
+		return err
+	}
+
+	it := &Iterator[T]{root: root, end: root.len()}
+	var prev *Key
+	for it.Next() {
+		k := it.Key()
+		if prev != nil && prev.Compare(k) >= 0 {
+			log.Close()
+			return fmt.Errorf("%w: %s is not after %s", ErrCorrupt, k, prev)
+		}
+		prev = &k
+	}
+
+	d.log = log
+	d.set.root = root
+
+	return nil
+}
+
+func (d *DurableSet[T]) loadSnapshot() (*treapNode[T], error) {
+	f, err := os.Open(filepath.Join(d.dir, durableSnapshotName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var root *treapNode[T]
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var w durableWrite[T]
+		if err := dec.Decode(&w); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+
+		// Snapshots are written in order so appending is all that's needed.
+		root = merge(root, leaf(w.Key, w.Value))
+	}
+
+	return root, nil
+}
+
+// replayWrites applies a log record, deletes of missing keys are ignored and
+// writes to existing keys overwrite them so that replaying is idempotent.
+func replayWrites[T any](root *treapNode[T], writes []durableWrite[T]) *treapNode[T] {
+	for _, w := range writes {
+		root, _, _ = root.delete(w.Key)
+		if !w.Delete {
+			root, _, _ = root.insert(w.Key, w.Value)
+		}
+	}
+	return root
+}
+
+func syncDir(dir string) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	// Not every platform supports syncing a directory, the rename itself is
+	// still atomic so this is best effort.
+	_ = f.Sync()
+}
@@ -0,0 +1,175 @@
+package lexorank
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurableSet_Recover(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	dir := t.TempDir()
+
+	d, err := OpenDurableSet[string](dir, DurableOptions{Sync: SyncAlways})
+	r.NoError(err)
+
+	for i, v := range []string{"a", "b", "c", "d"} {
+		_, err := d.InsertAt(uint(i), v)
+		r.NoError(err)
+	}
+	_, err = d.Move(0, 3)
+	r.NoError(err)
+
+	k, _, _ := d.At(0)
+	found, err := d.Delete(k)
+	r.NoError(err)
+	a.True(found)
+
+	wantKeys, wantValues := collect(d.Iter())
+	r.NoError(d.Close())
+
+	d, err = OpenDurableSet[string](dir, DurableOptions{Sync: SyncAlways})
+	r.NoError(err)
+	defer d.Close()
+
+	keys, values := collect(d.Iter())
+	a.Equal([]string{"c", "d", "a"}, values)
+	a.Equal(wantValues, values)
+	a.Equal(wantKeys, keys)
+}
+
+func TestDurableSet_TornWrite(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	dir := t.TempDir()
+
+	d, err := OpenDurableSet[int](dir, DurableOptions{Sync: SyncNever})
+	r.NoError(err)
+	r.NoError(d.Insert(mustKey("0|a"), 1))
+	r.NoError(d.Insert(mustKey("0|b"), 2))
+	r.NoError(d.Close())
+
+	// Simulate a crash half way through writing a record.
+	f, err := os.OpenFile(filepath.Join(dir, durableLogName), os.O_APPEND|os.O_WRONLY, 0)
+	r.NoError(err)
+	_, err = f.WriteString(`{"writes":[{"key":"0|c","val`)
+	r.NoError(err)
+	r.NoError(f.Close())
+
+	d, err = OpenDurableSet[int](dir, DurableOptions{Sync: SyncNever})
+	r.NoError(err)
+
+	_, values := collect(d.Iter())
+	a.Equal([]int{1, 2}, values)
+
+	// The torn record is discarded so new writes land on a clean line.
+	r.NoError(d.Insert(mustKey("0|c"), 3))
+	r.NoError(d.Close())
+
+	d, err = OpenDurableSet[int](dir, DurableOptions{Sync: SyncNever})
+	r.NoError(err)
+	defer d.Close()
+
+	_, values = collect(d.Iter())
+	a.Equal([]int{1, 2, 3}, values)
+}
+
+func TestDurableSet_Compaction(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	opts := DurableOptions{Sync: SyncBatch, SyncEvery: 10, CompactEvery: 50}
+
+	d, err := OpenDurableSet[int](dir, opts)
+	r.NoError(err)
+
+	for i := range 120 {
+		_, err := d.InsertAt(uint(min(i, 1)), i)
+		r.NoError(err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, durableSnapshotName))
+	r.NoError(err)
+	a.NotZero(info.Size())
+	a.Equal(20, d.records, "the log only holds what was written since the last compaction")
+
+	wantKeys, wantValues := collect(d.Iter())
+	r.NoError(d.Close())
+
+	d, err = OpenDurableSet[int](dir, opts)
+	r.NoError(err)
+
+	keys, values := collect(d.Iter())
+	a.Equal(wantValues, values)
+	a.Equal(wantKeys, keys)
+
+	// Simulate a crash between writing the snapshot and truncating the log, the
+	// stale records are replayed over the snapshot and change nothing.
+	r.NoError(d.Insert(mustKey("0|0"), -1))
+	stale, err := os.ReadFile(filepath.Join(dir, durableLogName))
+	r.NoError(err)
+	r.NotEmpty(stale)
+
+	r.NoError(d.Compact())
+	wantKeys, wantValues = collect(d.Iter())
+	r.NoError(d.Close())
+	r.NoError(os.WriteFile(filepath.Join(dir, durableLogName), stale, 0o644))
+
+	d, err = OpenDurableSet[int](dir, opts)
+	r.NoError(err)
+	defer d.Close()
+
+	keys, values = collect(d.Iter())
+	a.Equal(wantValues, values)
+	a.Equal(wantKeys, keys)
+	a.Equal(121, d.Len())
+}
+
+func TestDurableSet_Corrupt(t *testing.T) {
+	r := require.New(t)
+
+	dir := t.TempDir()
+	snapshot := `{"key":"0|b","value":1}` + "\n" + `{"key":"0|a","value":2}` + "\n"
+	r.NoError(os.WriteFile(filepath.Join(dir, durableSnapshotName), []byte(snapshot), 0o644))
+
+	_, err := OpenDurableSet[int](dir, DurableOptions{})
+	r.ErrorIs(err, ErrCorrupt)
+}
+
+func TestDurableSet_CorruptLog(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	dir := t.TempDir()
+
+	d, err := OpenDurableSet[int](dir, DurableOptions{Sync: SyncNever})
+	r.NoError(err)
+	for i, k := range []string{"0|a", "0|b", "0|c", "0|d", "0|e"} {
+		r.NoError(d.Insert(mustKey(k), i))
+	}
+	r.NoError(d.Close())
+
+	// Damage a complete record in the middle of the log.
+	path := filepath.Join(dir, durableLogName)
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	lines[1] = []byte("{\"writes\":[{\"key\":\n")
+	damaged := bytes.Join(lines, nil)
+	r.NoError(os.WriteFile(path, damaged, 0o644))
+
+	_, err = OpenDurableSet[int](dir, DurableOptions{Sync: SyncNever})
+	r.ErrorIs(err, ErrCorrupt)
+
+	after, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal(damaged, after, "the log is left untouched")
+}
@@ -0,0 +1,164 @@
+package lexorank
+
+import (
+	"fmt"
+)
+
+var (
+	ErrConflict      = fmt.Errorf("key changed since the operation was recorded")
+	ErrNothingToUndo = fmt.Errorf("nothing to undo")
+	ErrNothingToRedo = fmt.Errorf("nothing to redo")
+)
+
+// Op is an invertible record of every key that was rewritten by a single list
+// operation. Any set of Changes can be turned into an Op with a conversion.
+type Op Changes
+
+// Apply sets every item in the operation to its new key. Before anything is
+// written, every item is checked to still hold its old key, if any of them has
+// since been changed by something else, nothing is written and ErrConflict is
+// returned.
+func (o Op) Apply() error {
+	for _, c := range o {
+		if c.Item.GetKey().Compare(c.Old) != 0 {
+			return ErrConflict
+		}
+	}
+
+	for _, c := range o {
+		c.Item.SetKey(c.New)
+	}
+
+	return nil
+}
+
+// Invert returns an operation that reverses this one, applying it will put all
+// the items back to their old keys.
+func (o Op) Invert() Op {
+	inv := make(Op, len(o))
+	for i, c := range o {
+		inv[len(o)-1-i] = Change{Item: c.Item, Old: c.New, New: c.Old}
+	}
+	return inv
+}
+
+// InsertOp is Insert for an item that isn't in the list, such as one dragged
+// in from elsewhere. The item is given the new key and the returned operation
+// holds its change after any neighbours that were rebalanced to make room, so
+// undoing it puts the item back where it came from.
+func (l ReorderableList) InsertOp(item Reorderable, position uint) (*Key, Op, error) {
+	before := l.keys()
+
+	k, err := l.Insert(position)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return k, l.placeOp(before, item, *k), nil
+}
+
+// AppendOp is Append for an item that isn't in the list, see InsertOp.
+func (l ReorderableList) AppendOp(item Reorderable) (Key, Op) {
+	before := l.keys()
+	k := l.Append()
+	return k, l.placeOp(before, item, k)
+}
+
+// PrependOp is Prepend for an item that isn't in the list, see InsertOp.
+func (l ReorderableList) PrependOp(item Reorderable) (Key, Op) {
+	before := l.keys()
+	k := l.Prepend()
+	return k, l.placeOp(before, item, k)
+}
+
+// placeOp gives item the key k and returns the changes made to the list since
+// before followed by the item's own change.
+func (l ReorderableList) placeOp(before Keys, item Reorderable, k Key) Op {
+	changes := l.changes(before)
+	changes = append(changes, Change{Item: item, Old: item.GetKey(), New: k})
+	item.SetKey(k)
+	return Op(changes)
+}
+
+// NormaliseOp is Normalise but also returns the operation that describes every
+// key that was rewritten.
+func (l ReorderableList) NormaliseOp() Op {
+	before := l.keys()
+	l.Normalise()
+	return Op(l.changes(before))
+}
+
+// History is a bounded undo and redo stack of operations. Undoing restores the
+// keys of the items in an operation, if you keep a ReorderableList around you
+// will need to re-sort it afterwards since items may have changed places.
+type History struct {
+	limit int
+	undo  []Op
+	redo  []Op
+}
+
+// NewHistory creates a history that remembers at most limit operations, the
+// oldest operation is forgotten once the limit is reached. A limit of zero or
+// less means the history is unbounded.
+func NewHistory(limit int) *History {
+	return &History{limit: limit}
+}
+
+// Push records an operation that has just been performed. Recording a new
+// operation discards anything that could have been redone. Empty operations
+// are ignored.
+func (h *History) Push(op Op) {
+	if len(op) == 0 {
+		return
+	}
+
+	h.undo = append(h.undo, op)
+	h.redo = nil
+
+	if h.limit > 0 && len(h.undo) > h.limit {
+		h.undo = h.undo[len(h.undo)-h.limit:]
+	}
+}
+
+func (h *History) CanUndo() bool { return len(h.undo) > 0 }
+func (h *History) CanRedo() bool { return len(h.redo) > 0 }
+
+// Undo reverts the most recent operation and returns the operation that was
+// applied to do so. If any item in the operation has been changed since it was
+// recorded, ErrConflict is returned and nothing is changed.
+func (h *History) Undo() (Op, error) {
+	if len(h.undo) == 0 {
+		return nil, ErrNothingToUndo
+	}
+
+	op := h.undo[len(h.undo)-1]
+
+	inv := op.Invert()
+	if err := inv.Apply(); err != nil {
+		return nil, err
+	}
+
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, op)
+
+	return inv, nil
+}
+
+// Redo re-applies the most recently undone operation. The same conflict rules
+// as Undo apply.
+func (h *History) Redo() (Op, error) {
+	if len(h.redo) == 0 {
+		return nil, ErrNothingToRedo
+	}
+
+	op := h.redo[len(h.redo)-1]
+
+	if err := op.Apply(); err != nil {
+		return nil, err
+	}
+
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, op)
+
+	return op, nil
+}
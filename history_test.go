@@ -0,0 +1,168 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func keyStrings(l ReorderableList) []string {
+	out := make([]string, len(l))
+	for i := range l {
+		out[i] = l[i].GetKey().String()
+	}
+	return out
+}
+
+func TestOp_ApplyInvert(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	list := ReorderableList{
+		item(0, "1|a"),
+		item(1, "1|b"),
+		item(2, "1|c"),
+	}
+	original := keyStrings(list)
+
+	op := list.NormaliseOp()
+	r.Len(op, 3)
+	normalised := keyStrings(list)
+
+	r.NoError(op.Invert().Apply())
+	a.Equal(original, keyStrings(list))
+
+	r.NoError(op.Apply())
+	a.Equal(normalised, keyStrings(list))
+
+	a.Equal(ErrConflict, op.Apply(), "items are no longer at their old keys")
+}
+
+func TestReorderableList_InsertOp(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	list := ReorderableList{
+		item(0, "1|aaaaaa"),
+		item(1, "1|aaaaab"),
+	}
+
+	dragged := item(2, "1|z")
+
+	k, op, err := list.InsertOp(dragged, 1)
+	r.NoError(err)
+	a.Equal(*k, dragged.GetKey())
+	a.True(k.Compare(list[0].GetKey()) > 0)
+	a.True(k.Compare(list[1].GetKey()) < 0)
+
+	r.Greater(len(op), 1, "the rebalanced neighbours are recorded")
+	a.Equal(Change{Item: dragged, Old: mustKey("1|z"), New: *k}, op[len(op)-1])
+
+	r.NoError(op.Invert().Apply())
+	a.Equal([]string{"1|aaaaaa", "1|aaaaab"}, keyStrings(list))
+	a.Equal("1|z", dragged.GetKey().String(), "the item is back where it came from")
+	r.NoError(op.Apply())
+
+	_, op, err = list.InsertOp(item(3, "1|y"), 1)
+	r.NoError(err)
+	a.Len(op, 1, "no rebalance needed, only the item is recorded")
+}
+
+func TestHistory_UndoAppend(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	list := ReorderableList{item(0, "1|a")}
+	dragged := item(1, "0|a")
+
+	h := NewHistory(10)
+
+	k, op := list.AppendOp(dragged)
+	h.Push(op)
+	a.Equal(k, dragged.GetKey())
+
+	_, err := h.Undo()
+	r.NoError(err)
+	a.Equal("0|a", dragged.GetKey().String())
+
+	_, op = list.PrependOp(dragged)
+	h.Push(op)
+	r.True(dragged.GetKey().Compare(list[0].GetKey()) < 0)
+
+	_, err = h.Undo()
+	r.NoError(err)
+	a.Equal("0|a", dragged.GetKey().String())
+}
+
+func TestHistory_UndoRedo(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	list := ReorderableList{
+		item(0, "1|a"),
+		item(1, "1|b"),
+		item(2, "1|c"),
+	}
+	original := keyStrings(list)
+
+	h := NewHistory(10)
+	a.False(h.CanUndo())
+
+	changes, err := list.MoveMany([]uint{0}, 2)
+	r.NoError(err)
+	h.Push(Op(changes))
+	moved := keyStrings(list)
+
+	_, err = h.Undo()
+	r.NoError(err)
+	a.ElementsMatch(original, keyStrings(list))
+	a.True(h.CanRedo())
+
+	_, err = h.Undo()
+	a.Equal(ErrNothingToUndo, err)
+
+	_, err = h.Redo()
+	r.NoError(err)
+	a.Equal(moved, keyStrings(list))
+
+	_, err = h.Redo()
+	a.Equal(ErrNothingToRedo, err)
+}
+
+func TestHistory_Conflict(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	list := ReorderableList{
+		item(0, "1|a"),
+		item(1, "1|b"),
+	}
+
+	h := NewHistory(10)
+	h.Push(list.NormaliseOp())
+
+	// Someone else moves an item after the operation was recorded.
+	list[1].SetKey(mustKey("1|y"))
+	untouched := list[0].GetKey()
+
+	_, err := h.Undo()
+	a.Equal(ErrConflict, err)
+	a.Equal(untouched, list[0].GetKey(), "nothing is written on conflict")
+	r.True(h.CanUndo())
+}
+
+func TestHistory_Limit(t *testing.T) {
+	a := assert.New(t)
+
+	list := ReorderableList{item(0, "1|a")}
+
+	h := NewHistory(2)
+	for range 3 {
+		h.Push(list.NormaliseOp())
+		list[0].SetKey(mustKey("1|a"))
+	}
+	h.Push(nil)
+
+	a.Len(h.undo, 2)
+}
@@ -0,0 +1,57 @@
+package lexorank
+
+import (
+	"iter"
+	"sort"
+)
+
+// All yields every item of the list with its index, in order.
+func (l ReorderableList) All() iter.Seq2[int, Reorderable] {
+	return func(yield func(int, Reorderable) bool) {
+		for i, item := range l {
+			if !yield(i, item) {
+				return
+			}
+		}
+	}
+}
+
+// Backward yields every item of the list with its index, in reverse order.
+func (l ReorderableList) Backward() iter.Seq2[int, Reorderable] {
+	return func(yield func(int, Reorderable) bool) {
+		for i := len(l) - 1; i >= 0; i-- {
+			if !yield(i, l[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Range yields every item of a sorted list with a key that is at least lo and
+// less than hi, with its index.
+func (l ReorderableList) Range(lo, hi Key) iter.Seq2[int, Reorderable] {
+	return func(yield func(int, Reorderable) bool) {
+		start := sort.Search(len(l), func(i int) bool { return l[i].GetKey().Compare(lo) >= 0 })
+		for i := start; i < len(l) && l[i].GetKey().Compare(hi) < 0; i++ {
+			if !yield(i, l[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Crowded yields every item whose gap to the next item is less than threshold,
+// in units of the last rank character, with its index. CrowdedGap is a sensible
+// threshold.
+func (l ReorderableList) Crowded(threshold int64) iter.Seq2[int, Reorderable] {
+	return func(yield func(int, Reorderable) bool) {
+		for i := 0; i < len(l)-1; i++ {
+			if l[i+1].GetKey().position()-l[i].GetKey().position() >= threshold {
+				continue
+			}
+			if !yield(i, l[i]) {
+				return
+			}
+		}
+	}
+}
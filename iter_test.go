@@ -0,0 +1,45 @@
+package lexorank
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorderableList_Iterators(t *testing.T) {
+	a := assert.New(t)
+
+	l := ReorderableList{
+		item(1, "0|a"),
+		item(2, "0|b"),
+		item(3, "0|b0001"),
+		item(4, "0|c"),
+		item(5, "0|c00001"),
+	}
+
+	var order []int
+	for _, it := range l.All() {
+		order = append(order, it.(*Item).ID)
+	}
+	a.Equal([]int{1, 2, 3, 4, 5}, order)
+
+	order = nil
+	for i, it := range l.Backward() {
+		a.Equal(l[i], it)
+		order = append(order, it.(*Item).ID)
+		if i == 2 {
+			break
+		}
+	}
+	a.Equal([]int{5, 4, 3}, order)
+
+	byIndex := maps.Collect(l.Range(mustKey("0|b"), mustKey("0|c")))
+	a.Equal([]int{1, 2}, slices.Sorted(maps.Keys(byIndex)))
+
+	a.Empty(maps.Collect(l.Range(mustKey("0|d"), mustKey("0|z"))))
+
+	a.Equal([]int{1, 3}, slices.Sorted(maps.Keys(maps.Collect(l.Crowded(CrowdedGap)))))
+	a.Equal([]int{3}, slices.Sorted(maps.Keys(maps.Collect(l.Crowded(2)))))
+}
@@ -0,0 +1,319 @@
+package lexorank
+
+import (
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrEntryExists   = fmt.Errorf("entry already exists")
+	ErrSequenceGap   = fmt.Errorf("operation sequence has a gap")
+	ErrReplayDiverge = fmt.Errorf("replay diverged from recorded key")
+)
+
+type OperationKind string
+
+const (
+	OperationInsert    OperationKind = "insert"
+	OperationMove      OperationKind = "move"
+	OperationRemove    OperationKind = "remove"
+	OperationNormalise OperationKind = "normalise"
+	OperationRebalance OperationKind = "rebalance"
+)
+
+// Operation is a single serialisable event recorded by a Journal. Insert and
+// Move carry the key that was generated so a replay can verify it arrives at
+// the same result, Rebalance carries every key that was rewritten to make room
+// for the Insert or Move that follows it.
+type Operation struct {
+	Seq      uint64        `json:"seq"`
+	Kind     OperationKind `json:"kind"`
+	ID       string        `json:"id,omitempty"`
+	Position uint          `json:"position,omitempty"`
+	Key      *Key          `json:"key,omitempty"`
+	Keys     []Entry       `json:"keys,omitempty"`
+}
+
+// Entry is an item in a Journal, identified by an ID of your choosing.
+type Entry struct {
+	ID  string `json:"id"`
+	Key Key    `json:"key"`
+}
+
+func (e *Entry) GetKey() Key  { return e.Key }
+func (e *Entry) SetKey(k Key) { e.Key = k }
+
+// Snapshot is a checkpoint of a Journal's list after a given operation, a
+// replay can start from a snapshot rather than from the very first operation.
+type Snapshot struct {
+	Seq     uint64  `json:"seq"`
+	Entries []Entry `json:"entries"`
+}
+
+// Journal is a ReorderableList of entries where every mutation is recorded as
+// an Operation. Replaying the operations, either from the start or from a
+// Snapshot, deterministically rebuilds the exact same list, which makes the
+// operation log useful for auditing why an item ended up where it did and for
+// building read models.
+type Journal struct {
+	list   ReorderableList
+	seq    uint64
+	events []Operation
+}
+
+func NewJournal() *Journal {
+	return &Journal{}
+}
+
+// NewJournalFrom restores a journal from a snapshot. Only operations recorded
+// after the snapshot will be returned by Events.
+func NewJournalFrom(s Snapshot) *Journal {
+	j := &Journal{seq: s.Seq, list: make(ReorderableList, len(s.Entries))}
+	for i, e := range s.Entries {
+		j.list[i] = &Entry{ID: e.ID, Key: e.Key}
+	}
+	return j
+}
+
+// Replay rebuilds a list from the very first operation.
+func Replay(events []Operation) (ReorderableList, error) {
+	return ReplayFrom(Snapshot{}, events)
+}
+
+// ReplayFrom rebuilds a list starting at a snapshot. Operations that are older
+// than the snapshot are skipped, so the full log can be passed in.
+func ReplayFrom(s Snapshot, events []Operation) (ReorderableList, error) {
+	j := NewJournalFrom(s)
+	for _, e := range events {
+		if err := j.Apply(e); err != nil {
+			return nil, err
+		}
+	}
+	return j.List(), nil
+}
+
+// List returns the entries of the journal in order.
+func (j *Journal) List() ReorderableList {
+	return slices.Clone(j.list)
+}
+
+// Events returns every operation recorded or applied by this journal.
+func (j *Journal) Events() []Operation {
+	return slices.Clone(j.events)
+}
+
+// Snapshot captures the current state of the journal.
+func (j *Journal) Snapshot() Snapshot {
+	s := Snapshot{Seq: j.seq, Entries: make([]Entry, len(j.list))}
+	for i, e := range j.list {
+		s.Entries[i] = *e.(*Entry)
+	}
+	return s
+}
+
+// Insert adds a new entry at the given position.
+func (j *Journal) Insert(id string, position uint) (Key, error) {
+	if j.index(id) != -1 {
+		return Key{}, ErrEntryExists
+	}
+
+	k, changes, err := j.insert(&Entry{ID: id}, position)
+	if err != nil {
+		return Key{}, err
+	}
+
+	j.recordRebalance(changes)
+	j.record(Operation{Kind: OperationInsert, ID: id, Position: position, Key: &k})
+
+	return k, nil
+}
+
+// Move moves an existing entry to the given position, which is an index into
+// the list as it looks once the entry has been taken out.
+func (j *Journal) Move(id string, position uint) (Key, error) {
+	k, changes, err := j.move(id, position)
+	if err != nil {
+		return Key{}, err
+	}
+
+	j.recordRebalance(changes)
+	j.record(Operation{Kind: OperationMove, ID: id, Position: position, Key: &k})
+
+	return k, nil
+}
+
+// Remove deletes an entry from the list.
+func (j *Journal) Remove(id string) error {
+	if err := j.remove(id); err != nil {
+		return err
+	}
+
+	j.record(Operation{Kind: OperationRemove, ID: id})
+
+	return nil
+}
+
+// Normalise evenly distributes every entry across the key space.
+func (j *Journal) Normalise() {
+	j.list.Normalise()
+	j.record(Operation{Kind: OperationNormalise})
+}
+
+// Apply performs a recorded operation against the journal. Operations that are
+// already part of the journal are ignored, which makes it safe to apply a log
+// that overlaps with a snapshot. If an Insert or Move does not arrive at the
+// recorded key, ErrReplayDiverge is returned. The journal is left unchanged by
+// an operation that fails, so it can still be used afterwards.
+func (j *Journal) Apply(e Operation) error {
+	if e.Seq <= j.seq {
+		return nil
+	}
+	if e.Seq != j.seq+1 {
+		return fmt.Errorf("%w: expected %d, got %d", ErrSequenceGap, j.seq+1, e.Seq)
+	}
+
+	restore := j.save()
+	if err := j.apply(e); err != nil {
+		restore()
+		return err
+	}
+
+	j.seq = e.Seq
+	j.events = append(j.events, e)
+
+	return nil
+}
+
+func (j *Journal) apply(e Operation) error {
+	switch e.Kind {
+	case OperationInsert, OperationMove:
+		var k Key
+		var changes Changes
+		var err error
+
+		if e.Kind == OperationInsert {
+			if j.index(e.ID) != -1 {
+				return ErrEntryExists
+			}
+			k, changes, err = j.insert(&Entry{ID: e.ID}, e.Position)
+		} else {
+			k, changes, err = j.move(e.ID, e.Position)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Any rebalance was recorded, and so replayed, before this operation.
+		if len(changes) > 0 || e.Key == nil || k.Compare(*e.Key) != 0 {
+			return fmt.Errorf("%w: operation %d", ErrReplayDiverge, e.Seq)
+		}
+
+	case OperationRemove:
+		if err := j.remove(e.ID); err != nil {
+			return err
+		}
+
+	case OperationNormalise:
+		j.list.Normalise()
+
+	case OperationRebalance:
+		for _, entry := range e.Keys {
+			i := j.index(entry.ID)
+			if i == -1 {
+				return ErrItemNotFound
+			}
+			j.list[i].SetKey(entry.Key)
+		}
+
+	default:
+		return fmt.Errorf("unknown operation kind: %q", e.Kind)
+	}
+
+	return nil
+}
+
+// save returns a function that puts the list, and every key in it, back the
+// way it is now.
+func (j *Journal) save() func() {
+	list, keys := slices.Clone(j.list), j.list.keys()
+	return func() {
+		for i, e := range list {
+			e.SetKey(keys[i])
+		}
+		j.list = list
+	}
+}
+
+func (j *Journal) record(e Operation) {
+	j.seq++
+	e.Seq = j.seq
+	j.events = append(j.events, e)
+}
+
+func (j *Journal) recordRebalance(changes Changes) {
+	if len(changes) == 0 {
+		return
+	}
+
+	keys := make([]Entry, len(changes))
+	for i, c := range changes {
+		keys[i] = Entry{ID: c.Item.(*Entry).ID, Key: c.New}
+	}
+
+	j.record(Operation{Kind: OperationRebalance, Keys: keys})
+}
+
+func (j *Journal) index(id string) int {
+	return slices.IndexFunc(j.list, func(r Reorderable) bool { return r.(*Entry).ID == id })
+}
+
+func (j *Journal) insert(e *Entry, position uint) (Key, Changes, error) {
+	before := j.list.keys()
+
+	k, err := j.list.Insert(position)
+	if err != nil {
+		// Neighbours may have been rebalanced before the insert gave up.
+		for i, item := range j.list {
+			item.SetKey(before[i])
+		}
+		return Key{}, nil, err
+	}
+
+	e.Key = *k
+	changes := j.list.changes(before)
+	j.list = slices.Insert(j.list, int(position), Reorderable(e))
+
+	return *k, changes, nil
+}
+
+func (j *Journal) move(id string, position uint) (Key, Changes, error) {
+	i := j.index(id)
+	if i == -1 {
+		return Key{}, nil, ErrItemNotFound
+	}
+	if position >= uint(len(j.list)) {
+		return Key{}, nil, ErrOutOfBounds
+	}
+
+	e := j.list[i].(*Entry)
+	j.list = slices.Delete(j.list, i, i+1)
+
+	k, changes, err := j.insert(e, position)
+	if err != nil {
+		j.list = slices.Insert(j.list, i, Reorderable(e))
+		return Key{}, nil, err
+	}
+
+	return k, changes, nil
+}
+
+func (j *Journal) remove(id string) error {
+	i := j.index(id)
+	if i == -1 {
+		return ErrItemNotFound
+	}
+
+	j.list = slices.Delete(j.list, i, i+1)
+
+	return nil
+}
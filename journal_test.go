@@ -0,0 +1,148 @@
+package lexorank
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entries(l ReorderableList) []string {
+	out := make([]string, len(l))
+	for i, e := range l {
+		out[i] = fmt.Sprintf("%s=%s", e.(*Entry).ID, e.GetKey())
+	}
+	return out
+}
+
+func TestJournal_Replay(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	rng := rand.New(rand.NewSource(42))
+	j := NewJournal()
+
+	var snapshot Snapshot
+	for i := range 500 {
+		n := len(j.List())
+		switch {
+		case n > 0 && rng.Intn(5) == 0:
+			id := j.List()[rng.Intn(n)].(*Entry).ID
+			_, err := j.Move(id, uint(rng.Intn(n)))
+			r.NoError(err)
+		case n > 0 && rng.Intn(10) == 0:
+			id := j.List()[rng.Intn(n)].(*Entry).ID
+			r.NoError(j.Remove(id))
+		case rng.Intn(100) == 0:
+			j.Normalise()
+		default:
+			// Always inserting next to the same place is the quickest way to
+			// exhaust the key space and force rebalances.
+			_, err := j.Insert(fmt.Sprint(i), uint(min(n, 1)))
+			r.NoError(err)
+		}
+
+		if i == 250 {
+			snapshot = j.Snapshot()
+		}
+	}
+
+	events := j.Events()
+	kinds := map[OperationKind]int{}
+	for _, e := range events {
+		kinds[e.Kind]++
+	}
+	a.NotZero(kinds[OperationRebalance], "the test should exercise rebalances")
+
+	data, err := json.Marshal(events)
+	r.NoError(err)
+
+	var decoded []Operation
+	r.NoError(json.Unmarshal(data, &decoded))
+
+	replayed, err := Replay(decoded)
+	r.NoError(err)
+	a.Equal(entries(j.List()), entries(replayed))
+	a.True(replayed.IsSorted())
+
+	data, err = json.Marshal(snapshot)
+	r.NoError(err)
+
+	var checkpoint Snapshot
+	r.NoError(json.Unmarshal(data, &checkpoint))
+
+	resumed, err := ReplayFrom(checkpoint, decoded)
+	r.NoError(err)
+	a.Equal(entries(j.List()), entries(resumed))
+}
+
+func TestJournal_Apply_Errors(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	j := NewJournal()
+	_, err := j.Insert("a", 0)
+	r.NoError(err)
+	_, err = j.Insert("b", 1)
+	r.NoError(err)
+	_, err = j.Insert("a", 0)
+	a.Equal(ErrEntryExists, err)
+	a.Equal(ErrItemNotFound, j.Remove("c"))
+	_, err = j.Move("a", 2)
+	a.Equal(ErrOutOfBounds, err)
+
+	events := j.Events()
+	last := len(events) - 1
+	r.Equal(OperationInsert, events[last].Kind)
+
+	tampered := append([]Operation{}, events...)
+	tampered[last].Key = &Middle
+	_, err = Replay(tampered)
+	a.ErrorIs(err, ErrReplayDiverge)
+
+	_, err = Replay(events[1:])
+	a.ErrorIs(err, ErrSequenceGap)
+
+	_, err = Replay([]Operation{{Seq: 1, Kind: "shuffle"}})
+	a.Error(err)
+}
+
+func TestJournal_Apply_Unchanged(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	src := NewJournal()
+	for i, id := range []string{"a", "b", "c"} {
+		_, err := src.Insert(id, uint(i))
+		r.NoError(err)
+	}
+	_, err := src.Move("a", 2)
+	r.NoError(err)
+
+	events := src.Events()
+	last := len(events) - 1
+	r.Equal(OperationMove, events[last].Kind)
+
+	j := NewJournal()
+	for _, e := range events[:last] {
+		r.NoError(j.Apply(e))
+	}
+	before := entries(j.List())
+
+	tampered := events[last]
+	tampered.Key = &Middle
+	a.ErrorIs(j.Apply(tampered), ErrReplayDiverge)
+	a.Equal(before, entries(j.List()), "a diverging move is undone")
+
+	a.Equal(ErrItemNotFound, j.Apply(Operation{Seq: tampered.Seq, Kind: OperationRebalance, Keys: []Entry{
+		{ID: "b", Key: Top},
+		{ID: "x", Key: Top},
+	}}))
+	a.Equal(before, entries(j.List()), "a partly applied rebalance is undone")
+
+	r.NoError(j.Apply(events[last]))
+	a.Equal(entries(src.List()), entries(j.List()))
+}
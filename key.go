@@ -10,6 +10,7 @@ import (
 	"math"
 	"math/rand"
 	"strconv"
+	"strings"
 )
 
 var ErrRebalance = fmt.Errorf("rebalance required")
@@ -68,13 +69,20 @@ func MiddleOf(b uint8) Key {
 }
 
 const (
-	keyLength  = 8 // the full key length "0|aaaaaa"
-	rankLength = 6 // the part after the |: "aaaaaa"
+	keyLength    = 8 // the full key length "0|aaaaaa"
+	rankLength   = 6 // the part after the |: "aaaaaa"
+	suffixLength = 6 // the optional site suffix after the /: "0|aaaaaa/zzzzzz"
 )
 
+// SuffixSeparator marks the start of a site suffix. It sorts below every rank
+// character, so a suffixed key always sorts directly after the same key without
+// a suffix and before any longer rank that starts with it.
+const SuffixSeparator = '/'
+
 type Key struct {
-	raw    []byte // "0|aaaaaa"
+	raw    []byte // "0|aaaaaa/b"
 	rank   Rank   // "aaaaaa"
+	suffix []byte // "b"
 	bucket uint8  // 0
 }
 
@@ -86,10 +94,22 @@ func (k Key) GoString() string {
 	return string(k.raw)
 }
 
+// Compare orders keys byte-wise, the same way a database would. Site suffixes
+// need no special treatment thanks to the choice of SuffixSeparator.
 func (k Key) Compare(b Key) int {
 	return bytes.Compare(k.raw, b.raw)
 }
 
+// Suffix returns the site suffix of the key, or an empty string if there isn't
+// one.
+func (k Key) Suffix() string {
+	return string(k.suffix)
+}
+
+func (k Key) Bucket() uint8 {
+	return k.bucket
+}
+
 func (k *Key) SetBucket(b uint8) {
 	if b > 2 {
 		b = 0
@@ -118,7 +138,9 @@ func (r Rank) atMax(i int) byte {
 }
 
 func ParseKey(s string) (*Key, error) {
-	if len(s) > keyLength {
+	s, suffix, hasSuffix := strings.Cut(s, string(SuffixSeparator))
+
+	if len(s) > keyLength || len(s) < 3 {
 		return nil, fmt.Errorf("invalid key length: %d", len(s))
 	}
 
@@ -129,7 +151,34 @@ func ParseKey(s string) (*Key, error) {
 
 	rank := []byte(s[2:])
 
-	return parseRaw(uint8(bucket), rank)
+	k, err := parseRaw(uint8(bucket), rank)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasSuffix {
+		if err := validateSuffix([]byte(suffix)); err != nil {
+			return nil, err
+		}
+		k.suffix = []byte(suffix)
+		k.raw = append(append(k.raw, SuffixSeparator), suffix...)
+	}
+
+	return k, nil
+}
+
+func validateSuffix(suffix []byte) error {
+	if len(suffix) == 0 || len(suffix) > suffixLength {
+		return fmt.Errorf("invalid suffix length: %d", len(suffix))
+	}
+
+	for _, b := range suffix {
+		if b < Minimum || b > Maximum {
+			return fmt.Errorf("invalid byte value: %c", b)
+		}
+	}
+
+	return nil
 }
 
 func parseRaw(bucket uint8, rank []byte) (*Key, error) {
@@ -196,9 +245,17 @@ func (k Key) Between(to Key) (*Key, bool) {
 		bucket: k.bucket,
 	}
 
+	// Once a character has been taken from the lower key that is less than the
+	// upper key's character, the new key is already ordered before the upper
+	// key and it no longer constrains the remaining characters.
+	below := false
+
 	for i := 0; ; i++ {
 		prevChar := k.rank.atMin(i)
 		nextChar := to.rank.atMax(i)
+		if below {
+			nextChar = Maximum
+		}
 
 		if prevChar == nextChar {
 			mk.rank = append(mk.rank, prevChar)
@@ -208,6 +265,7 @@ func (k Key) Between(to Key) (*Key, bool) {
 		m, ok := mid(prevChar, nextChar)
 		if !ok {
 			mk.rank = append(mk.rank, prevChar)
+			below = true
 			continue
 		}
 
@@ -233,32 +291,22 @@ func (k Key) Between(to Key) (*Key, bool) {
 	return mk, valid
 }
 
+// After returns the key distance steps after k, see Add.
 func (k Key) After(distance int64) (*Key, bool) {
-	index := decodeBase75(k.rank)
-	next := encodeBase75(index + distance)
-
-	n, err := parseRaw(k.bucket, next)
+	n, err := k.Add(distance)
 	if err != nil {
 		return nil, false
 	}
-
-	return n, true
+	return &n, true
 }
 
+// Before returns the key distance steps before k, see Sub.
 func (k Key) Before(distance int64) (*Key, bool) {
-	index := decodeBase75(k.rank)
-	if index-distance < 0 {
-		return nil, false
-	}
-
-	next := encodeBase75(index - distance)
-
-	n, err := parseRaw(k.bucket, next)
+	n, err := k.Sub(distance)
 	if err != nil {
 		return nil, false
 	}
-
-	return n, true
+	return &n, true
 }
 
 func mid(a, b byte) (byte, bool) {
@@ -300,6 +348,56 @@ func encodeBase75(val int64) []byte {
 	return out
 }
 
+// position returns the rank as a fixed-width offset into the key space. Short
+// ranks are padded with the minimum character, so "0|U" and "0|U00000" share
+// the same position.
+func (k Key) position() int64 {
+	var p int64
+	for i := 0; i < rankLength; i++ {
+		p = p*int64(len(charset)) + int64(bytes.IndexByte(charset, k.rank.atMin(i)))
+	}
+	return p
+}
+
+// keyAtPosition is the inverse of position. Trailing minimum characters are
+// trimmed so generated keys are as short as the position allows.
+func keyAtPosition(bucket uint8, p int64) Key {
+	rank := make(Rank, rankLength)
+	for i := rankLength - 1; i >= 0; i-- {
+		rank[i] = charset[p%int64(len(charset))]
+		p /= int64(len(charset))
+	}
+
+	end := len(rank)
+	for end > 1 && rank[end-1] == Minimum {
+		end--
+	}
+	rank = rank[:end]
+
+	return Key{
+		raw:    append([]byte{bucket + '0', '|'}, rank...),
+		rank:   rank,
+		bucket: bucket,
+	}
+}
+
+// spread returns n evenly spaced keys strictly between lo and hi. If the gap
+// is too small to hold n distinct keys, the boolean return value is false.
+func spread(lo, hi Key, n int) ([]Key, bool) {
+	from, to := lo.position(), hi.position()
+	if to-from <= int64(n) {
+		return nil, false
+	}
+
+	step := (to - from) / int64(n+1)
+	keys := make([]Key, n)
+	for i := range keys {
+		keys[i] = keyAtPosition(lo.bucket, from+step*int64(i+1))
+	}
+
+	return keys, true
+}
+
 func Random() Key {
 	f := rand.Float64()
 	return KeyAt(0, f)
@@ -96,7 +96,7 @@ func TestKey_After(t *testing.T) {
 
 	after, ok := start.After(10)
 	r.True(ok)
-	a.Equal("0|:", after.String())
+	a.Equal("0|00000:", after.String(), "distance is measured at full precision")
 
 	a.True(start.Compare(*after) < 0, "expected start < after")
 
@@ -278,3 +278,20 @@ func TestBetween_OrderIndependent(t *testing.T) {
 		t.Errorf("Between should be symmetric, but got %s vs %s", forward.String(), backward.String())
 	}
 }
+
+func TestKey_Between_AdjacentThenWider(t *testing.T) {
+	r := require.New(t)
+
+	// The first characters are adjacent so the lower one is taken, after which
+	// the upper key's following characters must not constrain the result.
+	current, err := ParseKey("0|1orazz")
+	r.NoError(err)
+
+	next, err := ParseKey("0|2jHzzz")
+	r.NoError(err)
+
+	got, ok := current.Between(*next)
+	r.True(ok)
+	r.True(current.Compare(*got) < 0, "%s must sort after %s", got, current)
+	r.True(got.Compare(*next) < 0, "%s must sort before %s", got, next)
+}
@@ -0,0 +1,34 @@
+package lexorank
+
+import (
+	"slices"
+)
+
+// Sort sorts the keys in place.
+func (ks Keys) Sort() {
+	slices.SortFunc(ks, Key.Compare)
+}
+
+// IsSorted reports whether every key is strictly greater than the one before,
+// like ReorderableList.IsSorted, so sorted keys with duplicates are not sorted
+// until they've been through Dedup.
+func (ks Keys) IsSorted() bool {
+	for i := 1; i < len(ks); i++ {
+		if ks[i-1].Compare(ks[i]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Search finds k in sorted keys, returning the index it's at, or the index it
+// would be inserted at to keep the keys sorted, and whether it was found.
+func (ks Keys) Search(k Key) (int, bool) {
+	return slices.BinarySearchFunc(ks, k, Key.Compare)
+}
+
+// Dedup removes consecutive duplicate keys, so sorted keys become unique. The
+// shortened slice is returned.
+func (ks Keys) Dedup() Keys {
+	return slices.CompactFunc(ks, func(a, b Key) bool { return a.Compare(b) == 0 })
+}
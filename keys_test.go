@@ -0,0 +1,45 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func keysOf(s ...string) Keys {
+	ks := make(Keys, len(s))
+	for i, v := range s {
+		ks[i] = mustKey(v)
+	}
+	return ks
+}
+
+func TestKeys_SortSearchDedup(t *testing.T) {
+	a := assert.New(t)
+
+	ks := keysOf("0|c", "0|a", "1|a", "0|b", "0|a", "0|a/1")
+	a.False(ks.IsSorted())
+
+	ks.Sort()
+	a.False(ks.IsSorted(), "duplicates aren't strictly increasing")
+	a.Equal(keysOf("0|a", "0|a", "0|a/1", "0|b", "0|c", "1|a"), ks)
+
+	ks = ks.Dedup()
+	a.True(ks.IsSorted())
+	a.Equal(keysOf("0|a", "0|a/1", "0|b", "0|c", "1|a"), ks)
+
+	i, ok := ks.Search(mustKey("0|b"))
+	a.True(ok)
+	a.Equal(2, i)
+
+	i, ok = ks.Search(mustKey("0|bU"))
+	a.False(ok)
+	a.Equal(3, i)
+
+	i, ok = ks.Search(mustKey("2|0"))
+	a.False(ok)
+	a.Equal(5, i)
+
+	a.True(Keys{}.IsSorted())
+	a.Empty(Keys{}.Dedup())
+}
@@ -0,0 +1,339 @@
+// Package lexorankhttp is a reference JSON API for reordering lists, built on
+// net/http and a small Store interface.
+//
+//	POST /lists/{id}/insert     {"id": "c", "after": "b"}
+//	POST /lists/{id}/move       {"id": "c", "before": "a"}
+//	POST /lists/{id}/normalise
+//	GET  /lists/{id}/validate
+//
+// Inserts and moves are placed relative to neighbouring items rather than by
+// index, so clients don't need to agree on the exact contents of the list. If
+// both before and after are given they must be adjacent, which catches clients
+// working from a stale view of the list.
+//
+// Every response carries the list version in an ETag header. Requests with an
+// If-Match header are rejected with 412 Precondition Failed if the list has
+// changed since.
+package lexorankhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Southclaws/lexorank"
+)
+
+var (
+	errBadRequest   = fmt.Errorf("bad request")
+	errPrecondition = fmt.Errorf("list version does not match If-Match")
+)
+
+// PlaceRequest is the body of insert and move requests. Before and After are
+// IDs of the items the item should be placed next to. An insert with neither
+// is appended to the end of the list.
+type PlaceRequest struct {
+	ID     string `json:"id"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// ChangeResponse is returned by every request that writes to a list.
+type ChangeResponse struct {
+	Version string `json:"version"`
+
+	// Key is the key of the inserted or moved item.
+	Key *lexorank.Key `json:"key,omitempty"`
+
+	// Changes holds every item whose key was written, including the inserted
+	// or moved item and any neighbours that were rebalanced.
+	Changes []*Item `json:"changes"`
+}
+
+// ValidateResponse describes the health of a list's keys.
+type ValidateResponse struct {
+	Version string `json:"version"`
+
+	// Valid is true when every key is strictly greater than the one before.
+	Valid bool `json:"valid"`
+
+	// Duplicates holds the IDs of items that share a key with the item before.
+	Duplicates []string `json:"duplicates"`
+
+	Stats lexorank.Stats `json:"stats"`
+}
+
+// ErrorResponse is the body of every failed request.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type handler struct {
+	store Store
+}
+
+// NewHandler returns the JSON API for lists held in the store.
+func NewHandler(store Store) http.Handler {
+	h := &handler{store: store}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /lists/{id}/insert", h.insert)
+	mux.HandleFunc("POST /lists/{id}/move", h.move)
+	mux.HandleFunc("POST /lists/{id}/normalise", h.normalise)
+	mux.HandleFunc("GET /lists/{id}/validate", h.validate)
+	mux.HandleFunc("POST /lists/{id}/validate", h.validate)
+
+	return mux
+}
+
+func (h *handler) insert(w http.ResponseWriter, r *http.Request) {
+	var req PlaceRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	h.update(w, r, func(items []*Item) (*Item, error) {
+		if req.ID == "" {
+			return nil, fmt.Errorf("%w: id is required", errBadRequest)
+		}
+		if index(items, req.ID) != -1 {
+			return nil, fmt.Errorf("%w: %s is already in the list", ErrConflict, req.ID)
+		}
+
+		item := &Item{ID: req.ID}
+		if err := place(items, item, req); err != nil {
+			return nil, err
+		}
+		return item, nil
+	})
+}
+
+func (h *handler) move(w http.ResponseWriter, r *http.Request) {
+	var req PlaceRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	h.update(w, r, func(items []*Item) (*Item, error) {
+		i := index(items, req.ID)
+		if i == -1 {
+			return nil, fmt.Errorf("%w: item %s", ErrNotFound, req.ID)
+		}
+		if req.Before == "" && req.After == "" {
+			return nil, fmt.Errorf("%w: before or after is required", errBadRequest)
+		}
+		if req.Before == req.ID || req.After == req.ID {
+			return nil, fmt.Errorf("%w: %v", errBadRequest, lexorank.ErrSelfAnchor)
+		}
+
+		item := items[i]
+		rest := append(items[:i:i], items[i+1:]...)
+		if err := place(rest, item, req); err != nil {
+			return nil, err
+		}
+		return item, nil
+	})
+}
+
+func (h *handler) normalise(w http.ResponseWriter, r *http.Request) {
+	h.update(w, r, func(items []*Item) (*Item, error) {
+		list(items).Normalise()
+		return nil, nil
+	})
+}
+
+func (h *handler) validate(w http.ResponseWriter, r *http.Request) {
+	items, version, err := h.load(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	l := list(items)
+	resp := ValidateResponse{
+		Version:    version,
+		Valid:      l.IsSorted(),
+		Duplicates: []string{},
+		Stats:      l.Stats(),
+	}
+	for i := 1; i < len(items); i++ {
+		if items[i].Key.Compare(items[i-1].Key) == 0 {
+			resp.Duplicates = append(resp.Duplicates, items[i].ID)
+		}
+	}
+
+	w.Header().Set("ETag", etag(version))
+	respond(w, http.StatusOK, resp)
+}
+
+// update loads a list, runs fn against it and saves every item whose key
+// changed, along with the item fn returns if it's new.
+func (h *handler) update(w http.ResponseWriter, r *http.Request, fn func(items []*Item) (*Item, error)) {
+	items, version, err := h.load(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	before := make(map[*Item]lexorank.Key, len(items))
+	for _, it := range items {
+		before[it] = it.Key
+	}
+
+	placed, err := fn(items)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	changes := []*Item{}
+	if placed != nil {
+		changes = append(changes, placed)
+	}
+	for _, it := range items {
+		if it != placed && it.Key.Compare(before[it]) != 0 {
+			changes = append(changes, it)
+		}
+	}
+
+	version, err = h.store.Save(r.Context(), r.PathValue("id"), version, changes)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	resp := ChangeResponse{Version: version, Changes: changes}
+	if placed != nil {
+		resp.Key = &placed.Key
+	}
+
+	w.Header().Set("ETag", etag(version))
+	respond(w, http.StatusOK, resp)
+}
+
+func (h *handler) load(r *http.Request) ([]*Item, string, error) {
+	items, version, err := h.store.Load(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if match := r.Header.Get("If-Match"); match != "" && !matches(match, version) {
+		return nil, "", errPrecondition
+	}
+
+	return items, version, nil
+}
+
+// place gives item a key next to its anchors in items, which must not contain
+// the item itself. Neighbours may be rebalanced to make room.
+func place(items []*Item, item *Item, req PlaceRequest) error {
+	position := len(items)
+
+	if req.Before != "" {
+		i := index(items, req.Before)
+		if i == -1 {
+			return fmt.Errorf("%w: item %s", ErrNotFound, req.Before)
+		}
+		position = i
+	}
+
+	if req.After != "" {
+		i := index(items, req.After)
+		if i == -1 {
+			return fmt.Errorf("%w: item %s", ErrNotFound, req.After)
+		}
+		if req.Before != "" && position != i+1 {
+			return fmt.Errorf("%w: %s and %s are not adjacent", ErrConflict, req.After, req.Before)
+		}
+		position = i + 1
+	}
+
+	k, err := list(items).InsertIn(0, uint(position))
+	if err != nil {
+		return err
+	}
+	item.SetKey(*k)
+
+	return nil
+}
+
+func list(items []*Item) lexorank.ReorderableList {
+	l := make(lexorank.ReorderableList, len(items))
+	for i, it := range items {
+		l[i] = it
+	}
+	return l
+}
+
+func index(items []*Item, id string) int {
+	for i, it := range items {
+		if it.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func etag(version string) string {
+	return `"` + version + `"`
+}
+
+// matches reports whether an If-Match header matches the version, the header
+// may hold several comma separated ETags or a wildcard.
+func matches(header, version string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag(version) {
+			return true
+		}
+	}
+	return false
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		fail(w, fmt.Errorf("%w: %v", errBadRequest, err))
+		return false
+	}
+	return true
+}
+
+// status maps an error to the HTTP status code it's reported with.
+func status(err error) int {
+	switch {
+	case errors.Is(err, errBadRequest), errors.Is(err, lexorank.ErrOutOfBounds):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errPrecondition):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrConflict), errors.Is(err, lexorank.ErrRebalance):
+		return http.StatusConflict
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func fail(w http.ResponseWriter, err error) {
+	code := status(err)
+
+	msg := err.Error()
+	if code == http.StatusInternalServerError {
+		msg = http.StatusText(code)
+	}
+
+	respond(w, code, ErrorResponse{Error: msg})
+}
+
+func respond(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
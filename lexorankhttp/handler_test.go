@@ -0,0 +1,236 @@
+package lexorankhttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/lexorank"
+)
+
+func newServer(t *testing.T, items ...string) (*httptest.Server, *MemoryStore) {
+	store := NewMemoryStore()
+
+	list := make([]Item, len(items))
+	for i := range items {
+		k, err := lexorank.ParseKey(items[i][strings.Index(items[i], "=")+1:])
+		require.NoError(t, err)
+		list[i] = Item{ID: items[i][:strings.Index(items[i], "=")], Key: *k}
+	}
+	store.Put("l", list...)
+
+	srv := httptest.NewServer(NewHandler(store))
+	t.Cleanup(srv.Close)
+
+	return srv, store
+}
+
+func post(t *testing.T, srv *httptest.Server, path, body string, header ...string) (*http.Response, map[string]any) {
+	req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	for i := 0; i < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	res, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	var out map[string]any
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&out))
+	return res, out
+}
+
+// order returns the IDs of a list in key order.
+func order(t *testing.T, store *MemoryStore) []string {
+	items, _, err := store.Load(context.Background(), "l")
+	require.NoError(t, err)
+
+	ids := make([]string, len(items))
+	for i, it := range items {
+		ids[i] = it.ID
+	}
+	return ids
+}
+
+func TestInsert(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	srv, store := newServer(t, "a=0|a", "b=0|b")
+
+	res, body := post(t, srv, "/lists/l/insert", `{"id": "c", "after": "a"}`)
+	r.Equal(http.StatusOK, res.StatusCode)
+	a.Equal(`"1"`, res.Header.Get("ETag"))
+	a.Equal("1", body["version"])
+	a.Equal("0|aU", body["key"])
+	a.Len(body["changes"], 1)
+	a.Equal([]string{"a", "c", "b"}, order(t, store))
+
+	res, _ = post(t, srv, "/lists/l/insert", `{"id": "d"}`)
+	r.Equal(http.StatusOK, res.StatusCode)
+	a.Equal([]string{"a", "c", "b", "d"}, order(t, store))
+
+	res, _ = post(t, srv, "/lists/l/insert", `{"id": "e", "before": "a"}`)
+	r.Equal(http.StatusOK, res.StatusCode)
+	a.Equal([]string{"e", "a", "c", "b", "d"}, order(t, store))
+}
+
+func TestInsert_Empty(t *testing.T) {
+	srv, store := newServer(t)
+
+	res, body := post(t, srv, "/lists/l/insert", `{"id": "a"}`)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, lexorank.Middle.String(), body["key"])
+	assert.Equal(t, []string{"a"}, order(t, store))
+}
+
+func TestInsert_Rebalances(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	srv, store := newServer(t, "a=0|a00000", "b=0|a00001", "c=0|b")
+
+	res, body := post(t, srv, "/lists/l/insert", `{"id": "x", "after": "a", "before": "b"}`)
+	r.Equal(http.StatusOK, res.StatusCode)
+	a.Greater(len(body["changes"].([]any)), 1, "neighbours were moved to make room")
+	a.Equal([]string{"a", "x", "b", "c"}, order(t, store))
+}
+
+func TestMove(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	srv, store := newServer(t, "a=0|a", "b=0|b", "c=0|c", "d=0|d")
+
+	res, body := post(t, srv, "/lists/l/move", `{"id": "d", "after": "a", "before": "b"}`)
+	r.Equal(http.StatusOK, res.StatusCode)
+	a.Len(body["changes"], 1)
+	a.Equal([]string{"a", "d", "b", "c"}, order(t, store))
+
+	res, _ = post(t, srv, "/lists/l/move", `{"id": "a", "after": "c"}`)
+	r.Equal(http.StatusOK, res.StatusCode)
+	a.Equal([]string{"d", "b", "c", "a"}, order(t, store))
+
+	res, _ = post(t, srv, "/lists/l/move", `{"id": "a", "before": "d"}`)
+	r.Equal(http.StatusOK, res.StatusCode)
+	a.Equal([]string{"a", "d", "b", "c"}, order(t, store))
+}
+
+func TestMove_Errors(t *testing.T) {
+	srv, _ := newServer(t, "a=0|a", "b=0|b", "c=0|c")
+
+	for _, tc := range []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"unknown item", "/lists/l/move", `{"id": "x", "after": "a"}`, http.StatusNotFound},
+		{"unknown anchor", "/lists/l/move", `{"id": "a", "after": "x"}`, http.StatusNotFound},
+		{"unknown list", "/lists/x/move", `{"id": "a", "after": "b"}`, http.StatusNotFound},
+		{"no anchor", "/lists/l/move", `{"id": "a"}`, http.StatusBadRequest},
+		{"self anchor", "/lists/l/move", `{"id": "a", "after": "a"}`, http.StatusBadRequest},
+		{"bad json", "/lists/l/move", `{"id": `, http.StatusBadRequest},
+		{"unknown field", "/lists/l/move", `{"id": "a", "index": 2}`, http.StatusBadRequest},
+		{"not adjacent", "/lists/l/move", `{"id": "c", "after": "b", "before": "a"}`, http.StatusConflict},
+		{"duplicate insert", "/lists/l/insert", `{"id": "a"}`, http.StatusConflict},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, body := post(t, srv, tc.path, tc.body)
+			assert.Equal(t, tc.want, res.StatusCode)
+			assert.NotEmpty(t, body["error"])
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	srv, store := newServer(t, "a=0|a", "b=0|b")
+
+	res, _ := post(t, srv, "/lists/l/insert", `{"id": "c"}`, "If-Match", `"1"`)
+	a.Equal(http.StatusPreconditionFailed, res.StatusCode)
+	a.Equal([]string{"a", "b"}, order(t, store))
+
+	res, _ = post(t, srv, "/lists/l/insert", `{"id": "c"}`, "If-Match", `"7", "0"`)
+	r.Equal(http.StatusOK, res.StatusCode)
+
+	res, _ = post(t, srv, "/lists/l/move", `{"id": "c", "before": "a"}`, "If-Match", res.Header.Get("ETag"))
+	r.Equal(http.StatusOK, res.StatusCode)
+
+	res, _ = post(t, srv, "/lists/l/normalise", ``, "If-Match", "*")
+	r.Equal(http.StatusOK, res.StatusCode)
+	a.Equal(`"3"`, res.Header.Get("ETag"))
+}
+
+// racingStore lets another writer in between every Load and Save.
+type racingStore struct {
+	*MemoryStore
+}
+
+func (s racingStore) Load(ctx context.Context, list string) ([]*Item, string, error) {
+	items, version, err := s.MemoryStore.Load(ctx, list)
+	s.MemoryStore.Put(list)
+	return items, version, err
+}
+
+func TestConflict(t *testing.T) {
+	store := NewMemoryStore()
+	store.Put("l", Item{ID: "a", Key: lexorank.Middle})
+
+	srv := httptest.NewServer(NewHandler(racingStore{store}))
+	defer srv.Close()
+
+	res, _ := post(t, srv, "/lists/l/insert", `{"id": "b"}`)
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
+
+func TestNormalise(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	srv, store := newServer(t, "a=0|a00000", "b=0|a00001", "c=0|a00002")
+
+	res, body := post(t, srv, "/lists/l/normalise", ``)
+	r.Equal(http.StatusOK, res.StatusCode)
+	a.Len(body["changes"], 3)
+	a.Nil(body["key"])
+	a.Equal([]string{"a", "b", "c"}, order(t, store))
+}
+
+func TestValidate(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	srv, _ := newServer(t, "a=0|a", "b=0|a", "c=0|b")
+
+	res, err := srv.Client().Get(srv.URL + "/lists/l/validate")
+	r.NoError(err)
+	defer res.Body.Close()
+	r.Equal(http.StatusOK, res.StatusCode)
+	a.Equal(`"0"`, res.Header.Get("ETag"))
+
+	var body ValidateResponse
+	r.NoError(json.NewDecoder(res.Body).Decode(&body))
+	a.False(body.Valid)
+	a.Equal([]string{"b"}, body.Duplicates)
+	a.Equal(3, body.Stats.Len)
+}
+
+func TestStatus(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(http.StatusBadRequest, status(lexorank.ErrOutOfBounds))
+	a.Equal(http.StatusConflict, status(lexorank.ErrRebalance))
+	a.Equal(http.StatusServiceUnavailable, status(context.Canceled))
+	a.Equal(http.StatusInternalServerError, status(io.ErrUnexpectedEOF))
+}
@@ -0,0 +1,113 @@
+package lexorankhttp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Southclaws/lexorank"
+)
+
+var (
+	ErrNotFound = fmt.Errorf("not found")
+	ErrConflict = fmt.Errorf("list was changed by another request")
+)
+
+// Item is a single entry of a list.
+type Item struct {
+	ID  string       `json:"id"`
+	Key lexorank.Key `json:"key"`
+}
+
+func (i *Item) GetKey() lexorank.Key  { return i.Key }
+func (i *Item) SetKey(k lexorank.Key) { i.Key = k }
+
+// Store is the storage the handler reads and writes lists through. Every list
+// has a version that changes whenever the list is written, it's used for the
+// ETag and If-Match headers and for optimistic concurrency.
+type Store interface {
+	// Load returns the items of a list in key order and its current version.
+	// ErrNotFound is returned if the list doesn't exist.
+	Load(ctx context.Context, list string) ([]*Item, string, error)
+
+	// Save inserts or updates the given items if the list is still at the given
+	// version, returning the new version. ErrConflict is returned if the list
+	// has been changed since it was loaded.
+	Save(ctx context.Context, list string, version string, items []*Item) (string, error)
+}
+
+// MemoryStore is a Store that keeps lists in memory, for tests and prototypes.
+type MemoryStore struct {
+	mu    sync.Mutex
+	lists map[string]*memoryList
+}
+
+type memoryList struct {
+	version int
+	items   map[string]lexorank.Key
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{lists: map[string]*memoryList{}}
+}
+
+// Put creates or replaces a list.
+func (s *MemoryStore) Put(list string, items ...Item) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := &memoryList{items: map[string]lexorank.Key{}}
+	if old, ok := s.lists[list]; ok {
+		l.version = old.version + 1
+	}
+	for _, it := range items {
+		l.items[it.ID] = it.Key
+	}
+	s.lists[list] = l
+}
+
+func (s *MemoryStore) Load(ctx context.Context, list string) ([]*Item, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.lists[list]
+	if !ok {
+		return nil, "", ErrNotFound
+	}
+
+	items := make([]*Item, 0, len(l.items))
+	for id, k := range l.items {
+		items = append(items, &Item{ID: id, Key: k})
+	}
+	slices.SortFunc(items, func(a, b *Item) int {
+		if c := a.Key.Compare(b.Key); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return items, strconv.Itoa(l.version), nil
+}
+
+func (s *MemoryStore) Save(ctx context.Context, list string, version string, items []*Item) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.lists[list]
+	if !ok {
+		return "", ErrNotFound
+	}
+	if strconv.Itoa(l.version) != version {
+		return "", ErrConflict
+	}
+
+	for _, it := range items {
+		l.items[it.ID] = it.Key
+	}
+	l.version++
+
+	return strconv.Itoa(l.version), nil
+}
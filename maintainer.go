@@ -0,0 +1,250 @@
+package lexorank
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MaintainerStore gives a Maintainer access to the lists it looks after.
+type MaintainerStore interface {
+	// Lists returns the identifiers of every list to scan.
+	Lists(ctx context.Context) ([]string, error)
+
+	// Load returns the items of a list in key order.
+	Load(ctx context.Context, list string) (ReorderableList, error)
+
+	// Save writes back the changed keys of a list. Lists may be changed by
+	// users between Load and Save, so implementations should only apply the
+	// changes if every item still holds its Old key, returning ErrConflict
+	// otherwise. Conflicting lists are skipped until the next run.
+	Save(ctx context.Context, list string, changes Changes) error
+}
+
+// Clock is the source of time for a Maintainer, so tests can drive it without
+// waiting.
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+type MaintainerOptions struct {
+	// Interval is the time between runs, it defaults to one minute.
+	Interval time.Duration
+
+	// Rate is the maximum number of keys written per second. Zero is no limit.
+	Rate int
+
+	// Budget is the maximum number of keys written in a single run, regions
+	// that don't fit are left for a later run. Zero is no limit.
+	Budget int
+
+	// Quiet reports whether it's a good moment to write, such as outside of
+	// business hours or while request latency is low. It's checked before each
+	// region is written, a nil function is always quiet.
+	Quiet func() bool
+
+	// OnError is called with errors from the store while running in the
+	// background, the failing list is skipped and the run carries on.
+	OnError func(list string, err error)
+
+	// Observer is told about every region that's written, tagged with the ID
+	// of its list. It defaults to the Observer installed with SetObserver.
+	Observer Observer
+
+	Clock Clock
+}
+
+// MaintenanceReport summarises a single run of a Maintainer.
+type MaintenanceReport struct {
+	Lists    int // lists scanned
+	Regions  int // crowded regions that were respaced
+	Written  int // keys written
+	Deferred int // crowded pairs left for a later run
+}
+
+// Maintainer periodically scans lists for crowded regions, adjacent keys that
+// are close to running out of room, and respaces them ahead of time so that
+// user inserts rarely have to rebalance inline.
+type Maintainer struct {
+	store MaintainerStore
+	opts  MaintainerOptions
+}
+
+func NewMaintainer(store MaintainerStore, opts MaintainerOptions) *Maintainer {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+	return &Maintainer{store: store, opts: opts}
+}
+
+// Run scans every list each Interval until the context is cancelled.
+func (m *Maintainer) Run(ctx context.Context) error {
+	for {
+		if _, err := m.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			m.report("", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.opts.Clock.After(m.opts.Interval):
+		}
+	}
+}
+
+// RunOnce scans every list once and respaces the crowded regions it finds,
+// within the configured rate and budget. It stops early if the context is
+// cancelled or it stops being quiet.
+func (m *Maintainer) RunOnce(ctx context.Context) (MaintenanceReport, error) {
+	var report MaintenanceReport
+
+	lists, err := m.store.Lists(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	stopped := false
+	for _, id := range lists {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		l, err := m.store.Load(ctx, id)
+		if err != nil {
+			m.report(id, err)
+			continue
+		}
+		report.Lists++
+
+		crowded := func(i int) bool {
+			return l[i+1].GetKey().position()-l[i].GetKey().position() < CrowdedGap
+		}
+
+		for i := 0; i < len(l)-1; i++ {
+			if !crowded(i) {
+				continue
+			}
+
+			if stopped {
+				report.Deferred++
+				continue
+			}
+			if m.opts.Quiet != nil && !m.opts.Quiet() {
+				stopped = true
+				report.Deferred++
+				continue
+			}
+
+			// Take the whole run of crowded pairs as one region.
+			end := i + 2
+			for end < len(l) && crowded(end-1) {
+				end++
+			}
+
+			before := l.keys()
+			end, whole := l.decrowd(i, end)
+			changes := l.changes(before)
+
+			if m.opts.Budget > 0 && report.Written+len(changes) > m.opts.Budget {
+				for j, k := range before {
+					l[j].SetKey(k)
+				}
+				stopped = true
+				report.Deferred++
+				continue
+			}
+
+			if err := m.store.Save(ctx, id, changes); err != nil {
+				if !errors.Is(err, ErrConflict) {
+					m.report(id, err)
+				}
+				break
+			}
+
+			report.Regions++
+			report.Written += len(changes)
+
+			ev := eventsFor(id, m.opts.Observer)
+			if whole {
+				ev.normalised(len(l), true)
+			} else {
+				ev.rebalanced(len(changes), 0)
+			}
+
+			if err := m.throttle(ctx, len(changes)); err != nil {
+				return report, err
+			}
+
+			// Everything up to the end of the respaced window is now spread out.
+			i = end - 1
+		}
+	}
+
+	return report, nil
+}
+
+// decrowd gives evenly spaced keys to l[start:end], growing the window until the
+// gaps between the new keys are at least twice CrowdedGap. If the window covers
+// the whole list, it's spread out as far as the key space allows. The end of
+// the respaced window is returned, along with whether it was the whole list.
+func (l ReorderableList) decrowd(start, end int) (int, bool) {
+	bucket := l[start].GetKey().bucket
+
+	for {
+		lo := BottomOf(bucket)
+		if start > 0 {
+			lo = l[start-1].GetKey()
+		}
+
+		hi := TopOf(bucket)
+		if end < len(l) {
+			hi = l[end].GetKey()
+		}
+
+		if (hi.position()-lo.position())/int64(end-start+1) >= 2*CrowdedGap {
+			keys, _ := spread(lo, hi, end-start)
+			for i, k := range keys {
+				l[start+i].SetKey(k)
+			}
+			return end, false
+		}
+
+		if start == 0 && end == len(l) {
+			for i := range l {
+				l[i].SetKey(normaliseKey(bucket, i, len(l)))
+			}
+			return end, true
+		}
+
+		grow := max(1, end-start)
+		start = max(0, start-grow)
+		end = min(len(l), end+grow)
+	}
+}
+
+// throttle waits long enough for n writes to stay within the rate limit.
+func (m *Maintainer) throttle(ctx context.Context, n int) error {
+	if m.opts.Rate <= 0 || n == 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-m.opts.Clock.After(time.Duration(n) * time.Second / time.Duration(m.opts.Rate)):
+		return nil
+	}
+}
+
+func (m *Maintainer) report(list string, err error) {
+	if m.opts.OnError != nil {
+		m.opts.OnError(list, err)
+	}
+}
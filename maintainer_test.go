@@ -0,0 +1,195 @@
+package lexorank
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memStore struct {
+	lists map[string]ReorderableList
+	saves int
+	stale map[string]bool
+}
+
+func (s *memStore) Lists(ctx context.Context) ([]string, error) {
+	var ids []string
+	for id := range s.lists {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (s *memStore) Load(ctx context.Context, list string) (ReorderableList, error) {
+	var out ReorderableList
+	for _, it := range s.lists[list] {
+		out = append(out, &Item{ID: it.(*Item).ID, Rank: it.GetKey()})
+	}
+	return out, nil
+}
+
+func (s *memStore) Save(ctx context.Context, list string, changes Changes) error {
+	if s.stale[list] {
+		return ErrConflict
+	}
+
+	s.saves++
+	for _, c := range changes {
+		for _, it := range s.lists[list] {
+			if it.(*Item).ID == c.Item.(*Item).ID {
+				it.SetKey(c.New)
+			}
+		}
+	}
+	sort.Sort(s.lists[list])
+	return nil
+}
+
+// fakeClock fires every timer immediately and records how long it was asked to
+// wait in total.
+type fakeClock struct {
+	now    time.Time
+	waited time.Duration
+	timers int
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waited += d
+	c.timers++
+	c.now = c.now.Add(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// crowdedList is a sparse list with a crowded run of n keys in the middle.
+func crowdedList(n int) ReorderableList {
+	l := ReorderableList{item(0, "0|A")}
+	for i := range n {
+		l = append(l, item(i+1, fmt.Sprintf("0|U0000%c", charset[i])))
+	}
+	return append(l, item(n+1, "0|n"))
+}
+
+func TestMaintainer_RunOnce(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	store := &memStore{lists: map[string]ReorderableList{
+		"a": crowdedList(4),
+		"b": {item(1, "0|a"), item(2, "0|b")},
+	}}
+	clock := &fakeClock{}
+
+	rec := &recorder{}
+	m := NewMaintainer(store, MaintainerOptions{Rate: 2, Clock: clock, Observer: rec})
+
+	report, err := m.RunOnce(context.Background())
+	r.NoError(err)
+	a.Equal([]string{fmt.Sprintf("a: rebalanced %d 0", report.Written)}, rec.events)
+	a.Equal(2, report.Lists)
+	a.Equal(1, report.Regions)
+	a.Equal(1, store.saves)
+	a.NotZero(report.Written)
+	a.Zero(report.Deferred)
+
+	a.Zero(store.lists["a"].Stats().Crowded)
+	a.Equal([]int{0, 1, 2, 3, 4, 5}, ids(store.lists["a"]))
+	a.Equal(time.Duration(report.Written)*time.Second/2, clock.waited)
+
+	report, err = m.RunOnce(context.Background())
+	r.NoError(err)
+	a.Zero(report.Regions, "nothing left to do")
+}
+
+func TestMaintainer_BudgetAndQuiet(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	store := &memStore{lists: map[string]ReorderableList{
+		"a": crowdedList(3),
+		"b": crowdedList(40),
+	}}
+
+	m := NewMaintainer(store, MaintainerOptions{Budget: 10, Clock: &fakeClock{}})
+
+	report, err := m.RunOnce(context.Background())
+	r.NoError(err)
+	a.Equal(1, report.Regions)
+	a.LessOrEqual(report.Written, 10)
+	a.NotZero(report.Deferred)
+	a.NotZero(store.lists["b"].Stats().Crowded, "too big for the budget")
+
+	quiet := false
+	m = NewMaintainer(store, MaintainerOptions{Quiet: func() bool { return quiet }, Clock: &fakeClock{}})
+
+	report, err = m.RunOnce(context.Background())
+	r.NoError(err)
+	a.Zero(report.Regions)
+	a.NotZero(report.Deferred)
+
+	quiet = true
+	report, err = m.RunOnce(context.Background())
+	r.NoError(err)
+	a.Zero(report.Deferred)
+	a.Zero(store.lists["b"].Stats().Crowded)
+}
+
+func TestMaintainer_Conflict(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	store := &memStore{
+		lists: map[string]ReorderableList{"a": crowdedList(3)},
+		stale: map[string]bool{"a": true},
+	}
+
+	var errs []error
+	m := NewMaintainer(store, MaintainerOptions{
+		Clock:   &fakeClock{},
+		OnError: func(list string, err error) { errs = append(errs, err) },
+	})
+
+	report, err := m.RunOnce(context.Background())
+	r.NoError(err)
+	a.Zero(report.Regions)
+	a.Empty(errs, "conflicts are expected and not reported")
+}
+
+func TestMaintainer_Run(t *testing.T) {
+	a := assert.New(t)
+
+	store := &memStore{lists: map[string]ReorderableList{"a": crowdedList(3)}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := &fakeClock{}
+
+	m := NewMaintainer(store, MaintainerOptions{Interval: time.Hour, Clock: &cancelClock{clock, cancel, 3}})
+
+	a.Equal(context.Canceled, m.Run(ctx))
+	a.Equal(3*time.Hour, clock.waited)
+	a.Zero(store.lists["a"].Stats().Crowded)
+}
+
+// cancelClock cancels the context once it has been waited on a number of times.
+type cancelClock struct {
+	*fakeClock
+	cancel func()
+	limit  int
+}
+
+func (c *cancelClock) After(d time.Duration) <-chan time.Time {
+	ch := c.fakeClock.After(d)
+	if c.timers >= c.limit {
+		c.cancel()
+		return make(chan time.Time)
+	}
+	return ch
+}
@@ -0,0 +1,126 @@
+package lexorank
+
+import (
+	"sort"
+)
+
+// Merge interleaves two lists that are each already ordered into a single list
+// using order to decide which item goes first, items from a win ties. Both
+// lists usually come from separate key spaces so their keys will not line up
+// once interleaved, rather than normalising the result, as many items as
+// possible keep their existing key and only the rest are given new ones.
+//
+// The returned changes are the items whose key had to be rewritten.
+func Merge(a, b ReorderableList, order func(x, y Reorderable) bool) (ReorderableList, Changes) {
+	merged := make(ReorderableList, 0, len(a)+len(b))
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if order(b[j], a[i]) {
+			merged = append(merged, b[j])
+			j++
+		} else {
+			merged = append(merged, a[i])
+			i++
+		}
+	}
+	merged = append(merged, a[i:]...)
+	merged = append(merged, b[j:]...)
+
+	return merged, merged.Repair()
+}
+
+// Split divides the list into two at the given index, the first list holds the
+// items before the index and the second list holds the rest. If reseed is set,
+// the second list is normalised into a fresh, evenly spaced key space, which is
+// useful when it's going to be used as its own list from now on, such as a new
+// page or section.
+//
+// The returned changes are the items of the second list that were reseeded, if
+// reseed is not set this is always empty.
+func (l ReorderableList) Split(at uint, reseed bool) (ReorderableList, ReorderableList, Changes, error) {
+	if at > uint(len(l)) {
+		return nil, nil, nil, ErrOutOfBounds
+	}
+
+	head := l[:at:at]
+	tail := l[at:]
+
+	if !reseed {
+		return head, tail, nil, nil
+	}
+
+	before := tail.keys()
+	tail.Normalise()
+
+	return head, tail, tail.changes(before), nil
+}
+
+// Repair gives new keys to the fewest items needed to make the list sorted in
+// its current order. The longest strictly increasing run of existing keys is
+// kept as-is and the items in between are respaced into the gaps around them.
+// Items with a zero value key, such as ones whose stored rank didn't parse, are
+// always given a new key.
+func (l ReorderableList) Repair() Changes {
+	return l.repair(events{})
+}
+
+func (l ReorderableList) repair(ev events) Changes {
+	before := l.keys()
+	keep := increasing(before)
+	settled := func(i int) bool { return keep[i] }
+
+	for i := 0; i < len(l); {
+		if keep[i] {
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(l) && !keep[end] {
+			end++
+		}
+
+		i = l.respace(i, end, settled, ev)
+	}
+
+	return l.changes(before)
+}
+
+// increasing marks the longest strictly increasing subsequence of keys. Zero
+// value keys are never kept, as they're not real positions in the key space.
+func increasing(keys Keys) []bool {
+	keep := make([]bool, len(keys))
+
+	var tails []int
+	prev := make([]int, len(keys))
+
+	for i, k := range keys {
+		if len(k.raw) == 0 {
+			continue
+		}
+
+		j := sort.Search(len(tails), func(x int) bool { return keys[tails[x]].Compare(k) >= 0 })
+		if j > 0 {
+			prev[i] = tails[j-1]
+		} else {
+			prev[i] = -1
+		}
+
+		if j == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[j] = i
+		}
+	}
+
+	if len(tails) == 0 {
+		return keep
+	}
+
+	for i := tails[len(tails)-1]; i >= 0; i = prev[i] {
+		keep[i] = true
+	}
+
+	return keep
+}
@@ -0,0 +1,115 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func byID(x, y Reorderable) bool { return x.(*Item).ID < y.(*Item).ID }
+
+func TestMerge(t *testing.T) {
+	a := assert.New(t)
+
+	left := ReorderableList{
+		item(0, "1|a"),
+		item(2, "1|c"),
+		item(4, "1|e"),
+		item(6, "1|g"),
+	}
+	right := ReorderableList{
+		item(1, "1|b"),
+		item(3, "1|0"),
+		item(5, "1|f"),
+	}
+
+	merged, changes := Merge(left, right, byID)
+
+	a.Equal([]int{0, 1, 2, 3, 4, 5, 6}, ids(merged))
+	a.True(merged.IsSorted())
+
+	// Every key except the one for item 3 is already increasing.
+	a.Len(changes, 1)
+	a.Equal(merged[3], changes[0].Item)
+	a.Equal("1|d", merged[3].GetKey().String())
+}
+
+func TestMerge_Rebalance(t *testing.T) {
+	a := assert.New(t)
+
+	left := ReorderableList{
+		item(0, "1|aaaaaa"),
+		item(2, "1|aaaaab"),
+		item(4, "1|aaaaac"),
+	}
+	right := ReorderableList{
+		item(1, "1|b"),
+		item(3, "1|c"),
+	}
+
+	merged, changes := Merge(left, right, byID)
+
+	a.Equal([]int{0, 1, 2, 3, 4}, ids(merged))
+	a.True(merged.IsSorted())
+	a.NotEmpty(changes)
+}
+
+func TestMerge_Empty(t *testing.T) {
+	a := assert.New(t)
+
+	right := ReorderableList{item(1, "1|b"), item(3, "1|c")}
+
+	merged, changes := Merge(nil, right, byID)
+	a.Equal([]int{1, 3}, ids(merged))
+	a.Empty(changes)
+}
+
+func TestReorderableList_Split(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	list := ReorderableList{
+		item(0, "1|a"),
+		item(1, "1|b"),
+		item(2, "1|c"),
+		item(3, "1|d"),
+	}
+
+	head, tail, changes, err := list.Split(1, false)
+	r.NoError(err)
+	a.Equal([]int{0}, ids(head))
+	a.Equal([]int{1, 2, 3}, ids(tail))
+	a.Empty(changes)
+
+	head, tail, changes, err = list.Split(2, true)
+	r.NoError(err)
+	a.Equal([]int{0, 1}, ids(head))
+	a.Equal([]int{2, 3}, ids(tail))
+	a.Len(changes, 2)
+	a.True(tail.IsSorted())
+	a.Equal("1|b", head[1].GetKey().String(), "head is never rewritten")
+
+	_, _, _, err = list.Split(5, false)
+	a.Equal(ErrOutOfBounds, err)
+}
+
+func TestReorderableList_Repair(t *testing.T) {
+	a := assert.New(t)
+
+	l := ReorderableList{
+		item(1, "0|a"),
+		item(2, "0|b"),
+		item(3, "0|b"),
+		&Item{ID: 4},
+		item(5, "0|c"),
+		item(6, "0|d"),
+	}
+
+	changes := l.Repair()
+	a.Len(changes, 2, "only the duplicate and the missing key are rewritten")
+	a.Contains([]int{2, 3}, changes[0].Item.(*Item).ID)
+	a.Equal(4, changes[1].Item.(*Item).ID)
+	a.True(l.IsSorted())
+	a.Equal([]int{1, 2, 3, 4, 5, 6}, ids(l))
+}
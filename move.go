@@ -0,0 +1,125 @@
+package lexorank
+
+import (
+	"fmt"
+	"sort"
+)
+
+var ErrDuplicateIndex = fmt.Errorf("duplicate index")
+
+// MoveMany moves the items at the given indices so they sit contiguously with
+// the first one at position to, keeping their original relative order. The
+// destination is an index into the list as it looks once the selected items
+// have been taken out, which is also the index of the first moved item after
+// the move has been applied.
+//
+// Rather than inserting each item individually, the whole block is given evenly
+// spaced keys in one go. If the gap at the destination is too small, the window
+// is widened over neighbouring items until the block fits, falling back to a
+// full normalisation as a last resort.
+//
+// The list is re-ordered in place to reflect the new keys and every item whose
+// key changed is returned so it can be written back to storage.
+func (l ReorderableList) MoveMany(indices []uint, to uint) (Changes, error) {
+	return l.moveMany(indices, to, events{})
+}
+
+func (l ReorderableList) moveMany(indices []uint, to uint, ev events) (Changes, error) {
+	sorted := make([]uint, len(indices))
+	copy(sorted, indices)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	selected := make(map[uint]bool, len(sorted))
+	for _, i := range sorted {
+		if i >= uint(len(l)) {
+			return nil, ErrOutOfBounds
+		}
+		if selected[i] {
+			return nil, ErrDuplicateIndex
+		}
+		selected[i] = true
+	}
+
+	if to > uint(len(l)-len(sorted)) {
+		return nil, ErrOutOfBounds
+	}
+
+	if len(sorted) == 0 {
+		return nil, nil
+	}
+
+	block := make(ReorderableList, 0, len(sorted))
+	for _, i := range sorted {
+		block = append(block, l[i])
+	}
+
+	rest := make(ReorderableList, 0, len(l)-len(block))
+	for i := range l {
+		if !selected[uint(i)] {
+			rest = append(rest, l[i])
+		}
+	}
+
+	// Write the new order back into the list before generating any keys so the
+	// rebalance operates on the final layout.
+	n := copy(l, rest[:to])
+	n += copy(l[n:], block)
+	copy(l[n:], rest[to:])
+
+	before := l.keys()
+
+	l.respace(int(to), int(to)+len(block), nil, ev)
+
+	return l.changes(before), nil
+}
+
+// respace assigns evenly spaced keys to every item in l[start:end] using the
+// items either side of the window as bounds. When there is not enough room, the
+// window is grown geometrically in both directions until it fits. If the window
+// grows to cover the entire list, it's normalised instead.
+//
+// The optional settled function reports whether the item at an index holds a
+// key that can be trusted as an upper bound. Unsettled items are swallowed into
+// the window when it grows over them.
+//
+// The end of the window that was eventually respaced is returned.
+func (l ReorderableList) respace(start, end int, settled func(int) bool, ev events) int {
+	bucket := l[start].GetKey().bucket
+	size := end - start
+
+	for {
+		lo := BottomOf(bucket)
+		if start > 0 {
+			lo = l[start-1].GetKey()
+		}
+
+		hi := TopOf(bucket)
+		if end < len(l) {
+			hi = l[end].GetKey()
+		}
+
+		keys, ok := spread(lo, hi, end-start)
+		if ok {
+			for i, k := range keys {
+				l[start+i].SetKey(k)
+			}
+			// Only neighbours that were swallowed into the window were moved to
+			// make room, the items the window started with needed new keys anyway.
+			ev.rebalanced(len(keys)-size, 0)
+			return end
+		}
+
+		if start == 0 && end == len(l) {
+			l.normalise(true, ev)
+			return end
+		}
+
+		grow := max(1, end-start)
+		start = max(0, start-grow)
+		end = min(len(l), end+grow)
+
+		for settled != nil && end < len(l) && !settled(end) {
+			end++
+		}
+	}
+}
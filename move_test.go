@@ -0,0 +1,112 @@
+package lexorank
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ids(l ReorderableList) []int {
+	out := make([]int, len(l))
+	for i := range l {
+		out[i] = l[i].(*Item).ID
+	}
+	return out
+}
+
+func TestReorderableList_MoveMany(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	list := ReorderableList{
+		item(0, "1|a"),
+		item(1, "1|b"),
+		item(2, "1|c"),
+		item(3, "1|d"),
+		item(4, "1|e"),
+		item(5, "1|f"),
+	}
+
+	changes, err := list.MoveMany([]uint{4, 1, 5}, 1)
+	r.NoError(err)
+
+	a.Equal([]int{0, 1, 4, 5, 2, 3}, ids(list))
+	a.True(sort.IsSorted(list))
+	a.True(list.IsSorted())
+
+	// Only the moved block needed new keys, the gap between a and c was enough.
+	r.Len(changes, 3)
+	for _, c := range changes {
+		a.Contains([]int{1, 4, 5}, c.Item.(*Item).ID)
+		a.Equal(c.New.String(), c.Item.GetKey().String())
+	}
+}
+
+func TestReorderableList_MoveMany_ToEnds(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	list := ReorderableList{
+		item(0, "1|a"),
+		item(1, "1|b"),
+		item(2, "1|c"),
+		item(3, "1|d"),
+	}
+
+	_, err := list.MoveMany([]uint{0, 2}, 2)
+	r.NoError(err)
+	a.Equal([]int{1, 3, 0, 2}, ids(list))
+	a.True(list.IsSorted())
+
+	_, err = list.MoveMany([]uint{2, 3}, 0)
+	r.NoError(err)
+	a.Equal([]int{0, 2, 1, 3}, ids(list))
+	a.True(list.IsSorted())
+}
+
+func TestReorderableList_MoveMany_Rebalance(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	list := ReorderableList{
+		item(0, "1|aaaaaa"),
+		item(1, "1|aaaaab"),
+		item(2, "1|aaaaac"),
+		item(3, "1|aaaaad"),
+		item(4, "1|zzzzzz"),
+	}
+
+	changes, err := list.MoveMany([]uint{3, 4}, 1)
+	r.NoError(err)
+
+	a.Equal([]int{0, 3, 4, 1, 2}, ids(list))
+	a.True(list.IsSorted())
+
+	// The neighbours had to be pulled into the window to make room.
+	a.Greater(len(changes), 2)
+}
+
+func TestReorderableList_MoveMany_Errors(t *testing.T) {
+	a := assert.New(t)
+
+	list := ReorderableList{
+		item(0, "1|a"),
+		item(1, "1|b"),
+		item(2, "1|c"),
+	}
+
+	_, err := list.MoveMany([]uint{3}, 0)
+	a.Equal(ErrOutOfBounds, err)
+
+	_, err = list.MoveMany([]uint{1, 1}, 0)
+	a.Equal(ErrDuplicateIndex, err)
+
+	_, err = list.MoveMany([]uint{0, 1}, 2)
+	a.Equal(ErrOutOfBounds, err)
+
+	changes, err := list.MoveMany(nil, 0)
+	a.NoError(err)
+	a.Empty(changes)
+}
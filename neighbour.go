@@ -0,0 +1,209 @@
+package lexorank
+
+import (
+	"context"
+	"fmt"
+)
+
+var ErrSelfAnchor = fmt.Errorf("item cannot be placed next to itself")
+
+// NeighbourFetcher loads items adjacent to a key from storage, so keys can be
+// generated without loading every sibling of a list.
+type NeighbourFetcher interface {
+	// Before returns up to n items with keys less than k, the n closest to k,
+	// in key order. Returning fewer than n means the start of the list.
+	Before(ctx context.Context, k Key, n int) (ReorderableList, error)
+
+	// After returns up to n items with keys greater than k, the n closest to
+	// k, in key order. Returning fewer than n means the end of the list.
+	After(ctx context.Context, k Key, n int) (ReorderableList, error)
+}
+
+// ObservedFetcher is a NeighbourFetcher for a single list that has its own
+// Observer. The rebalances done by InsertAfter, MoveAfter and the like are
+// reported to it, tagged with the list's ID, rather than to the Observer
+// installed with SetObserver.
+type ObservedFetcher interface {
+	NeighbourFetcher
+	Observer() (list string, o Observer)
+}
+
+// InsertAfter generates a key for a new item placed directly after anchor.
+//
+// Only the neighbours either side of the anchor are fetched at first. If there
+// is no room between them, a window of neighbours is fetched and respaced,
+// doubling in size until the new key fits. Every fetched item that was given a
+// new key is returned so it can be written back to storage.
+func InsertAfter(ctx context.Context, f NeighbourFetcher, anchor Reorderable) (Key, Changes, error) {
+	return place(ctx, f, anchor, true, nil)
+}
+
+// InsertBefore generates a key for a new item placed directly before anchor.
+func InsertBefore(ctx context.Context, f NeighbourFetcher, anchor Reorderable) (Key, Changes, error) {
+	return place(ctx, f, anchor, false, nil)
+}
+
+// MoveAfter gives item a new key that places it directly after anchor. The
+// returned changes always include the item itself.
+func MoveAfter(ctx context.Context, f NeighbourFetcher, item, anchor Reorderable) (Changes, error) {
+	return move(ctx, f, item, anchor, true)
+}
+
+// MoveBefore gives item a new key that places it directly before anchor.
+func MoveBefore(ctx context.Context, f NeighbourFetcher, item, anchor Reorderable) (Changes, error) {
+	return move(ctx, f, item, anchor, false)
+}
+
+func move(ctx context.Context, f NeighbourFetcher, item, anchor Reorderable, after bool) (Changes, error) {
+	old := item.GetKey()
+	if old.Compare(anchor.GetKey()) == 0 {
+		return nil, ErrSelfAnchor
+	}
+
+	k, changes, err := place(ctx, f, anchor, after, &old)
+	if err != nil {
+		return nil, err
+	}
+
+	item.SetKey(k)
+
+	return append(Changes{{Item: item, Old: old, New: k}}, changes...), nil
+}
+
+// place finds a key next to anchor, skipping the item with the key skip if it's
+// one of the fetched neighbours since it's the one being moved.
+//
+// The window holds w neighbours either side of the gap that may be rekeyed,
+// with the anchor counting as one of them, bounded by the next item out or the
+// end of the list.
+func place(ctx context.Context, f NeighbourFetcher, anchor Reorderable, after bool, skip *Key) (Key, Changes, error) {
+	at := anchor.GetKey()
+
+	var ev events
+	if of, ok := f.(ObservedFetcher); ok {
+		ev = eventsFor(of.Observer())
+	}
+
+	fetch := func(before bool, n int) (ReorderableList, bool, error) {
+		if n == 0 {
+			return nil, false, nil
+		}
+
+		// One extra in case the item being moved is among them.
+		want := n
+		if skip != nil {
+			want++
+		}
+
+		var items ReorderableList
+		var err error
+		if before {
+			items, err = f.Before(ctx, at, want)
+		} else {
+			items, err = f.After(ctx, at, want)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		end := len(items) < want
+
+		kept := make(ReorderableList, 0, len(items))
+		for _, item := range items {
+			if skip == nil || item.GetKey().Compare(*skip) != 0 {
+				kept = append(kept, item)
+			}
+		}
+
+		// Keep the n closest to the anchor.
+		if len(kept) > n {
+			if before {
+				kept = kept[len(kept)-n:]
+			} else {
+				kept = kept[:n]
+			}
+		}
+
+		return kept, end, nil
+	}
+
+	for w := 0; ; w = max(1, w*2) {
+		// The anchor takes one of the w slots on its own side, the other side
+		// needs an extra item for the bound.
+		nl, nr := w, w+1
+		if !after {
+			nl, nr = w+1, w
+		}
+
+		left, leftEnd, err := fetch(true, nl)
+		if err != nil {
+			return Key{}, nil, err
+		}
+		right, rightEnd, err := fetch(false, nr)
+		if err != nil {
+			return Key{}, nil, err
+		}
+
+		if after {
+			left = append(left, anchor)
+		} else {
+			right = append(ReorderableList{anchor}, right...)
+		}
+
+		lo, hi := BottomOf(at.bucket), TopOf(at.bucket)
+		if !leftEnd {
+			lo = left[0].GetKey()
+			left = left[1:]
+		}
+		if !rightEnd {
+			hi = right[len(right)-1].GetKey()
+			right = right[:len(right)-1]
+		}
+
+		if w == 0 {
+			// Items left over on a side that reached the end of the list sit
+			// between the bound and the gap, the closest one is the real bound.
+			if len(left) > 0 {
+				lo = left[len(left)-1].GetKey()
+			}
+			if len(right) > 0 {
+				hi = right[0].GetKey()
+			}
+			if k, ok := lo.Between(hi); ok && lo.Compare(*k) < 0 && k.Compare(hi) < 0 {
+				ev.key(*k)
+				return *k, nil, nil
+			}
+			ev.betweenFailed(lo, hi)
+			continue
+		}
+
+		keys, ok := spread(lo, hi, len(left)+1+len(right))
+		if !ok {
+			if leftEnd && rightEnd {
+				return Key{}, nil, ErrRebalance
+			}
+			continue
+		}
+
+		window := append(append(left, nil), right...)
+		var changes Changes
+		var k Key
+
+		for i, item := range window {
+			if item == nil {
+				k = keys[i]
+				continue
+			}
+
+			old := item.GetKey()
+			if old.Compare(keys[i]) != 0 {
+				item.SetKey(keys[i])
+				changes = append(changes, Change{Item: item, Old: old, New: keys[i]})
+			}
+		}
+
+		ev.rebalanced(len(changes), 0)
+
+		return k, changes, nil
+	}
+}
@@ -0,0 +1,236 @@
+package lexorank
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceFetcher serves neighbours from a list kept in key order and counts how
+// many items it has handed out.
+type sliceFetcher struct {
+	list    ReorderableList
+	fetched int
+}
+
+func (f *sliceFetcher) index(k Key) int {
+	return sort.Search(len(f.list), func(i int) bool { return f.list[i].GetKey().Compare(k) >= 0 })
+}
+
+func (f *sliceFetcher) Before(ctx context.Context, k Key, n int) (ReorderableList, error) {
+	end := f.index(k)
+	out := f.list[max(0, end-n):end]
+	f.fetched += len(out)
+	return out, nil
+}
+
+func (f *sliceFetcher) After(ctx context.Context, k Key, n int) (ReorderableList, error) {
+	start := f.index(k)
+	if start < len(f.list) && f.list[start].GetKey().Compare(k) == 0 {
+		start++
+	}
+	out := f.list[start:min(len(f.list), start+n)]
+	f.fetched += len(out)
+	return out, nil
+}
+
+func (f *sliceFetcher) add(id int, k Key) {
+	f.list = append(f.list, &Item{ID: id, Rank: k})
+	sort.Sort(f.list)
+}
+
+func TestInsertAfter_NeighboursOnly(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	f := &sliceFetcher{}
+	for i, k := range []string{"0|a", "0|b", "0|c", "0|d", "0|e"} {
+		f.add(i, mustKey(k))
+	}
+
+	k, changes, err := InsertAfter(context.Background(), f, f.list[2])
+	r.NoError(err)
+	a.Empty(changes)
+	a.Equal("0|cU", k.String())
+	a.Equal(1, f.fetched, "only the next item is needed")
+
+	k, changes, err = InsertBefore(context.Background(), f, f.list[0])
+	r.NoError(err)
+	a.Empty(changes)
+	a.True(k.Compare(mustKey("0|a")) < 0)
+
+	k, _, err = InsertAfter(context.Background(), f, f.list[4])
+	r.NoError(err)
+	a.True(k.Compare(mustKey("0|e")) > 0)
+}
+
+func TestInsertAfter_GrowsWindow(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	// A crowded run somewhere inside a long, sparse list.
+	f := &sliceFetcher{}
+	for i := range 100 {
+		f.add(i, KeyAt(0, float64(i+1)/202))
+	}
+	crowded := []string{"0|n00000", "0|n00001", "0|n00002", "0|n00003"}
+	for i, k := range crowded {
+		f.add(100+i, mustKey(k))
+	}
+	for i := range 100 {
+		f.add(200+i, KeyAt(0, float64(i+102)/202))
+	}
+
+	var anchor Reorderable
+	for _, item := range f.list {
+		if item.(*Item).ID == 101 {
+			anchor = item
+		}
+	}
+	k, changes, err := InsertAfter(context.Background(), f, anchor)
+	r.NoError(err)
+	a.NotEmpty(changes)
+	a.Less(f.fetched, 20, "a small window was enough")
+
+	f.add(-1, k)
+	a.True(f.list.IsSorted())
+
+	next := 0
+	for i, item := range f.list {
+		if item.(*Item).ID == -1 {
+			next = i
+		}
+	}
+	a.Equal(anchor, f.list[next-1], "the new item sits directly after the anchor")
+
+	for _, c := range changes {
+		a.Equal(c.New, c.Item.GetKey())
+	}
+}
+
+func TestInsertAfter_StartOfList(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	f := &sliceFetcher{}
+	f.add(0, mustKey("0|000001"))
+	f.add(1, mustKey("0|000002"))
+
+	k, changes, err := InsertAfter(context.Background(), f, f.list[0])
+	r.NoError(err)
+	a.Len(changes, 2, "the window reached both ends of the list")
+
+	f.add(-1, k)
+	a.Equal([]int{0, -1, 1}, ids(f.list))
+}
+
+func TestMoveAfter(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	f := &sliceFetcher{}
+	for i, k := range []string{"0|a", "0|a0", "0|a00001", "0|b"} {
+		f.add(i, mustKey(k))
+	}
+
+	item, anchor := f.list[3], f.list[1]
+	changes, err := MoveAfter(context.Background(), f, item, anchor)
+	r.NoError(err)
+	r.NotEmpty(changes)
+	a.Equal(item, changes[0].Item)
+	a.Equal("0|b", changes[0].Old.String())
+
+	sort.Sort(f.list)
+	a.Equal([]int{0, 1, 3, 2}, ids(f.list))
+
+	changes, err = MoveBefore(context.Background(), f, f.list[0], f.list[3])
+	r.NoError(err)
+	a.Len(changes, 1)
+	sort.Sort(f.list)
+	a.Equal([]int{1, 3, 0, 2}, ids(f.list))
+
+	_, err = MoveAfter(context.Background(), f, f.list[0], f.list[0])
+	a.Equal(ErrSelfAnchor, err)
+}
+
+func TestMoveAfter_ListEnds(t *testing.T) {
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		name   string
+		item   int
+		anchor int
+		after  bool
+		want   []int
+	}{
+		{"before the second item", 3, 1, false, []int{0, 3, 1, 2}},
+		{"after the second to last item", 0, 2, true, []int{1, 2, 0, 3}},
+		{"before the first item", 2, 0, false, []int{2, 0, 1, 3}},
+		{"after the last item", 1, 3, true, []int{0, 2, 3, 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &sliceFetcher{}
+			for i, k := range []string{"0|a", "0|b", "0|c", "0|d"} {
+				f.add(i, mustKey(k))
+			}
+
+			item, anchor := f.list[tc.item], f.list[tc.anchor]
+
+			var err error
+			if tc.after {
+				_, err = MoveAfter(context.Background(), f, item, anchor)
+			} else {
+				_, err = MoveBefore(context.Background(), f, item, anchor)
+			}
+			require.NoError(t, err)
+
+			sort.Sort(f.list)
+			a.Equal(tc.want, ids(f.list))
+			a.True(f.list.IsSorted())
+		})
+	}
+}
+
+func TestMoveAfter_Random(t *testing.T) {
+	r := require.New(t)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	f := &sliceFetcher{}
+	for i := range 8 {
+		f.add(i, KeyAt(0, float64(i+1)/9))
+	}
+
+	for range 2000 {
+		i, j := rng.IntN(len(f.list)), rng.IntN(len(f.list))
+		if i == j {
+			continue
+		}
+		item, anchor := f.list[i], f.list[j]
+		after := rng.IntN(2) == 0
+
+		want := slices.Clone(f.list)
+		want = slices.Delete(want, i, i+1)
+		at := slices.Index(want, anchor)
+		if after {
+			at++
+		}
+		want = slices.Insert(want, at, item)
+
+		var err error
+		if after {
+			_, err = MoveAfter(context.Background(), f, item, anchor)
+		} else {
+			_, err = MoveBefore(context.Background(), f, item, anchor)
+		}
+		r.NoError(err)
+
+		sort.Sort(f.list)
+		r.Equal(ids(want), ids(f.list))
+		r.True(f.list.IsSorted())
+	}
+}
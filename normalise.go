@@ -0,0 +1,101 @@
+package lexorank
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// normaliseChunk is the number of keys a worker computes before reporting
+// progress and checking for cancellation.
+const normaliseChunk = 4096
+
+type NormaliseOptions struct {
+	// Workers is the number of goroutines computing keys, it defaults to
+	// GOMAXPROCS.
+	Workers int
+
+	// Progress is called with the number of keys computed so far each time a
+	// chunk of the list is done. It's always called from the goroutine that
+	// called NormaliseContext.
+	Progress func(done, total int)
+}
+
+// NormaliseContext is Normalise for very large lists. Since the key for each
+// index doesn't depend on any other, the list is split into chunks that are
+// computed in parallel.
+//
+// Keys are only written to the list once all of them have been computed, so if
+// the context is cancelled part way through, the list is left untouched and the
+// context's error is returned.
+func (l ReorderableList) NormaliseContext(ctx context.Context, opts NormaliseOptions) error {
+	return l.normaliseContext(ctx, opts, events{})
+}
+
+func (l ReorderableList) normaliseContext(ctx context.Context, opts NormaliseOptions, ev events) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(l) == 0 {
+		return nil
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	chunks := (len(l) + normaliseChunk - 1) / normaliseChunk
+	keys := make([]Key, len(l))
+
+	next := make(chan int)
+	done := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(workers, chunks) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range next {
+				start, end := c*normaliseChunk, min(len(l), (c+1)*normaliseChunk)
+				for i := start; i < end; i++ {
+					keys[i] = normaliseKey(l[i].GetKey().bucket, i, len(l))
+				}
+				done <- end - start
+			}
+		}()
+	}
+
+	go func() {
+	feed:
+		for c := range chunks {
+			select {
+			case next <- c:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(next)
+		wg.Wait()
+		close(done)
+	}()
+
+	completed := 0
+	for n := range done {
+		completed += n
+		if opts.Progress != nil {
+			opts.Progress(completed, len(l))
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for i, k := range keys {
+		l[i].SetKey(k)
+	}
+	ev.normalised(len(l), false)
+
+	return nil
+}
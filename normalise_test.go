@@ -0,0 +1,74 @@
+package lexorank
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func largeList(n int) ReorderableList {
+	l := make(ReorderableList, n)
+	for i := range l {
+		l[i] = &Item{ID: i, Rank: KeyAt(1, 0.5)}
+	}
+	return l
+}
+
+func TestReorderableList_NormaliseContext(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	want := largeList(100_000)
+	want.Normalise()
+
+	l := largeList(100_000)
+
+	var progress []int
+	err := l.NormaliseContext(context.Background(), NormaliseOptions{
+		Workers:  4,
+		Progress: func(done, total int) { progress = append(progress, done) },
+	})
+	r.NoError(err)
+
+	a.Equal(want.keys(), l.keys())
+	a.True(l.IsSorted())
+	a.Equal(uint8(1), l[0].GetKey().bucket)
+
+	r.NotEmpty(progress)
+	a.IsIncreasing(progress)
+	a.Equal(100_000, progress[len(progress)-1])
+
+	a.NoError(ReorderableList{}.NormaliseContext(context.Background(), NormaliseOptions{}))
+}
+
+func TestReorderableList_NormaliseContextCancel(t *testing.T) {
+	a := assert.New(t)
+
+	l := largeList(100_000)
+	before := l.keys()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a.Equal(context.Canceled, l.NormaliseContext(ctx, NormaliseOptions{}))
+	a.Equal(before, l.keys())
+
+	// Abort part way through from the progress callback.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	err := l.NormaliseContext(ctx, NormaliseOptions{
+		Workers: 2,
+		Progress: func(done, total int) {
+			calls++
+			if done >= total/4 {
+				cancel()
+			}
+		},
+	})
+	a.Equal(context.Canceled, err)
+	a.Equal(before, l.keys(), "nothing is written when cancelled")
+	a.Less(calls, 25)
+}
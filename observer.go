@@ -0,0 +1,259 @@
+package lexorank
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+)
+
+// LongKeyLength is the rank length at which generated keys are reported to the
+// Observer as getting long. Keys at the maximum length of 6 are reported too.
+const LongKeyLength = 5
+
+// Observer is notified of the work lists do to make room for new keys, so that
+// lists which rebalance too often can be found and alerted on.
+//
+// Every event carries the ID of the list it happened to. Lists are given an ID
+// and their own Observer with ObservedList, MaintainerOptions, an
+// ObservedFetcher or OrderedSet.SetObserver. Anything else reports to the
+// Observer installed with SetObserver with an empty list ID.
+//
+// Observers are called synchronously from list operations and must be safe for
+// concurrent use.
+type Observer interface {
+	// BetweenFailed is called when there's no room between two keys and the
+	// list has to be rebalanced to make some.
+	BetweenFailed(list string, lo, hi Key)
+
+	// Rebalanced is called after part of a list was given new keys to make
+	// room, touched is the number of items that were rewritten. Direction is 1
+	// if items after the gap were pushed towards the end, -1 if items before it
+	// were pushed towards the start and 0 if a window either side was respaced.
+	Rebalanced(list string, touched, direction int)
+
+	// Normalised is called when every key of a list of n items is rewritten.
+	// Forced is true when it was a fallback because a smaller rebalance wasn't
+	// possible, rather than an explicit call to Normalise.
+	Normalised(list string, n int, forced bool)
+
+	// LongKey is called when a generated key reaches LongKeyLength or the
+	// maximum length, threshold is the highest of the two that was reached.
+	LongKey(list string, k Key, threshold int)
+}
+
+type observerHolder struct{ Observer }
+
+var observer atomic.Pointer[observerHolder]
+
+// SetObserver installs the Observer used by lists that don't have their own,
+// nil removes it.
+func SetObserver(o Observer) {
+	if o == nil {
+		observer.Store(nil)
+		return
+	}
+	observer.Store(&observerHolder{o})
+}
+
+func globalObserver() Observer {
+	if h := observer.Load(); h != nil {
+		return h.Observer
+	}
+	return nil
+}
+
+// events reports what an operation did to a single list.
+type events struct {
+	list     string
+	observer Observer
+}
+
+// eventsFor reports to o under the list ID, or to the Observer installed with
+// SetObserver if o is nil.
+func eventsFor(list string, o Observer) events {
+	return events{list: list, observer: o}
+}
+
+func (e events) get() Observer {
+	if e.observer != nil {
+		return e.observer
+	}
+	return globalObserver()
+}
+
+func (e events) betweenFailed(lo, hi Key) {
+	if o := e.get(); o != nil {
+		o.BetweenFailed(e.list, lo, hi)
+	}
+}
+
+func (e events) rebalanced(touched, direction int) {
+	if touched == 0 {
+		return
+	}
+	if o := e.get(); o != nil {
+		o.Rebalanced(e.list, touched, direction)
+	}
+}
+
+// directionName names a Rebalanced direction for logs and metrics.
+func directionName(direction int) string {
+	switch {
+	case direction > 0:
+		return "forward"
+	case direction < 0:
+		return "backward"
+	}
+	return "both"
+}
+
+func (e events) normalised(n int, forced bool) {
+	if o := e.get(); o != nil {
+		o.Normalised(e.list, n, forced)
+	}
+}
+
+func (e events) key(k Key) {
+	o := e.get()
+	if o == nil {
+		return
+	}
+
+	switch {
+	case len(k.rank) >= rankLength:
+		o.LongKey(e.list, k, rankLength)
+	case len(k.rank) >= LongKeyLength:
+		o.LongKey(e.list, k, LongKeyLength)
+	}
+}
+
+// ObservedList is a ReorderableList with an ID and its own Observer, which is
+// told about every rebalance the list's operations do. If Observer is nil, the
+// one installed with SetObserver is used, still tagged with the ID.
+type ObservedList struct {
+	ReorderableList
+	ID       string
+	Observer Observer
+}
+
+func (l ObservedList) events() events {
+	return eventsFor(l.ID, l.Observer)
+}
+
+func (l ObservedList) Insert(position uint) (*Key, error) {
+	return l.ReorderableList.insert(position, l.events())
+}
+
+func (l ObservedList) InsertIn(bucket uint8, position uint) (*Key, error) {
+	return l.ReorderableList.insertIn(bucket, position, l.events())
+}
+
+func (l ObservedList) Append() Key {
+	return l.ReorderableList.append(l.events())
+}
+
+func (l ObservedList) Prepend() Key {
+	return l.ReorderableList.prepend(l.events())
+}
+
+func (l ObservedList) Normalise() {
+	l.ReorderableList.normalise(false, l.events())
+}
+
+func (l ObservedList) NormaliseContext(ctx context.Context, opts NormaliseOptions) error {
+	return l.ReorderableList.normaliseContext(ctx, opts, l.events())
+}
+
+func (l ObservedList) MoveMany(indices []uint, to uint) (Changes, error) {
+	return l.ReorderableList.moveMany(indices, to, l.events())
+}
+
+func (l ObservedList) Repair() Changes {
+	return l.ReorderableList.repair(l.events())
+}
+
+// SlogObserver writes every event to a structured logger. Forced
+// normalisations and keys at the maximum length are warnings, everything else
+// is logged at debug level.
+type SlogObserver struct {
+	Logger *slog.Logger
+}
+
+func NewSlogObserver(l *slog.Logger) *SlogObserver {
+	return &SlogObserver{Logger: l}
+}
+
+func (o *SlogObserver) BetweenFailed(list string, lo, hi Key) {
+	o.Logger.Debug("lexorank: no room between keys", "list", list, "lo", lo.String(), "hi", hi.String())
+}
+
+func (o *SlogObserver) Rebalanced(list string, touched, direction int) {
+	o.Logger.Debug("lexorank: rebalanced", "list", list, "touched", touched, "direction", directionName(direction))
+}
+
+func (o *SlogObserver) Normalised(list string, n int, forced bool) {
+	if forced {
+		o.Logger.Warn("lexorank: rebalance fell back to normalising the list", "list", list, "items", n)
+		return
+	}
+	o.Logger.Debug("lexorank: normalised", "list", list, "items", n)
+}
+
+func (o *SlogObserver) LongKey(list string, k Key, threshold int) {
+	if threshold >= rankLength {
+		o.Logger.Warn("lexorank: key at maximum length", "list", list, "key", k.String())
+		return
+	}
+	o.Logger.Debug("lexorank: long key", "list", list, "key", k.String(), "threshold", threshold)
+}
+
+// ExpvarObserver counts events in an expvar.Map, for example:
+//
+//	lexorank.SetObserver(lexorank.NewExpvarObserver(expvar.NewMap("lexorank")))
+//
+// The counters are between_failed, rebalances, rebalanced_items,
+// normalisations, forced_normalisations, rebalances_forward, rebalances_backward
+// and rebalances_both for each direction and long_keys_5 and long_keys_6 for
+// each key length threshold. Rebalances and forced normalisations of lists with
+// an ID are also counted per list in the rebalances_by_list map, so only use
+// list IDs with a bounded number of values.
+type ExpvarObserver struct {
+	m      *expvar.Map
+	byList *expvar.Map
+}
+
+func NewExpvarObserver(m *expvar.Map) *ExpvarObserver {
+	byList := new(expvar.Map)
+	m.Set("rebalances_by_list", byList)
+	return &ExpvarObserver{m: m, byList: byList}
+}
+
+func (o *ExpvarObserver) BetweenFailed(list string, lo, hi Key) {
+	o.m.Add("between_failed", 1)
+}
+
+func (o *ExpvarObserver) Rebalanced(list string, touched, direction int) {
+	o.m.Add("rebalances", 1)
+	o.m.Add("rebalances_"+directionName(direction), 1)
+	o.m.Add("rebalanced_items", int64(touched))
+	if list != "" {
+		o.byList.Add(list, 1)
+	}
+}
+
+func (o *ExpvarObserver) Normalised(list string, n int, forced bool) {
+	if !forced {
+		o.m.Add("normalisations", 1)
+		return
+	}
+	o.m.Add("forced_normalisations", 1)
+	if list != "" {
+		o.byList.Add(list, 1)
+	}
+}
+
+func (o *ExpvarObserver) LongKey(list string, k Key, threshold int) {
+	o.m.Add("long_keys_"+strconv.Itoa(threshold), 1)
+}
@@ -0,0 +1,245 @@
+package lexorank
+
+import (
+	"bytes"
+	"context"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) add(list, format string, args ...any) {
+	e := fmt.Sprintf(format, args...)
+	if list != "" {
+		e = list + ": " + e
+	}
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) BetweenFailed(list string, lo, hi Key) {
+	r.add(list, "between %s %s", lo, hi)
+}
+
+func (r *recorder) Rebalanced(list string, touched, direction int) {
+	r.add(list, "rebalanced %d %d", touched, direction)
+}
+
+func (r *recorder) Normalised(list string, n int, forced bool) {
+	r.add(list, "normalised %d %v", n, forced)
+}
+
+func (r *recorder) LongKey(list string, k Key, threshold int) {
+	r.add(list, "long %s %d", k, threshold)
+}
+
+func record(t *testing.T, o Observer) {
+	SetObserver(o)
+	t.Cleanup(func() { SetObserver(nil) })
+}
+
+func TestObserver_Rebalance(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	rec := &recorder{}
+	record(t, rec)
+
+	l := ReorderableList{item(1, "0|U"), item(2, "0|a"), item(3, "0|a00001")}
+	_, err := l.Insert(2)
+	r.NoError(err)
+
+	a.Equal([]string{
+		"between 0|a 0|a00001",
+		"rebalanced 1 -1",
+	}, rec.events)
+}
+
+func TestObserver_Normalise(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	rec := &recorder{}
+	record(t, rec)
+
+	l := ReorderableList{item(1, "0|a"), item(2, "0|a00001")}
+	_, err := l.Insert(1)
+	r.NoError(err)
+
+	a.Equal([]string{
+		"between 0|a 0|a00001",
+		"normalised 2 true",
+	}, rec.events)
+
+	rec.events = nil
+	l.Normalise()
+	a.Equal([]string{"normalised 2 false"}, rec.events)
+}
+
+func TestObserver_LongKey(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	rec := &recorder{}
+	record(t, rec)
+
+	_, err := ReorderableList{item(1, "0|a000"), item(2, "0|a001")}.Insert(1)
+	r.NoError(err)
+	_, err = ReorderableList{item(1, "0|a0000"), item(2, "0|a0001")}.Insert(1)
+	r.NoError(err)
+	_, err = ReorderableList{item(1, "0|a"), item(2, "0|b")}.Insert(1)
+	r.NoError(err)
+
+	a.Equal([]string{"long 0|a000U 5", "long 0|a0000U 6"}, rec.events)
+}
+
+func TestExpvarObserver(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	m := new(expvar.Map)
+	record(t, NewExpvarObserver(m))
+
+	l := ReorderableList{item(1, "0|U"), item(2, "0|a"), item(3, "0|a00001")}
+	_, err := l.Insert(2)
+	r.NoError(err)
+	_, err = ReorderableList{item(1, "0|a"), item(2, "0|a00001")}.Insert(1)
+	r.NoError(err)
+
+	a.Equal("2", m.Get("between_failed").String())
+	a.Equal("1", m.Get("rebalances").String())
+	a.Equal("1", m.Get("rebalanced_items").String())
+	a.Equal("1", m.Get("rebalances_backward").String())
+	a.Equal("1", m.Get("forced_normalisations").String())
+	a.Nil(m.Get("normalisations"))
+
+	_, err = ObservedList{ReorderableList: ReorderableList{item(1, "0|a"), item(2, "0|a00001")}, ID: "b"}.Insert(1)
+	r.NoError(err)
+	a.Equal(`{"b": 1}`, m.Get("rebalances_by_list").String())
+}
+
+func TestSlogObserver(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	record(t, NewSlogObserver(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	_, err := ReorderableList{item(1, "0|a"), item(2, "0|a00001")}.Insert(1)
+	r.NoError(err)
+
+	a.Contains(buf.String(), "level=WARN")
+	a.Contains(buf.String(), "rebalance fell back to normalising the list")
+	a.Contains(buf.String(), "items=2")
+	a.NotContains(buf.String(), "no room between keys", "debug events are filtered by the handler")
+
+	buf.Reset()
+	record(t, NewSlogObserver(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+
+	_, err = ReorderableList{item(1, "0|U"), item(2, "0|a"), item(3, "0|a00001")}.Insert(2)
+	r.NoError(err)
+	a.Contains(buf.String(), "touched=1 direction=backward")
+}
+
+func TestObservedList(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	global := &recorder{}
+	record(t, global)
+
+	own := &recorder{}
+	l := ObservedList{
+		ReorderableList: ReorderableList{item(1, "0|a"), item(2, "0|a00001")},
+		ID:              "board-1",
+		Observer:        own,
+	}
+	_, err := l.Insert(1)
+	r.NoError(err)
+
+	a.Equal([]string{
+		"board-1: between 0|a 0|a00001",
+		"board-1: normalised 2 true",
+	}, own.events)
+	a.Empty(global.events)
+
+	// Without an Observer the events go to the global one, still tagged.
+	l.Observer = nil
+	l.Normalise()
+	a.Equal([]string{"board-1: normalised 2 false"}, global.events)
+}
+
+func TestObserver_Respace(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	rec := &recorder{}
+	l := ObservedList{
+		ReorderableList: ReorderableList{item(1, "0|a"), item(2, "0|a00001"), item(3, "0|a00002"), item(4, "0|b"), item(5, "0|z")},
+		ID:              "l",
+		Observer:        rec,
+	}
+
+	// There's room after the last item, so nothing else has to move.
+	_, err := l.MoveMany([]uint{0}, 4)
+	r.NoError(err)
+	a.Empty(rec.events)
+
+	// No room between the crowded keys, the window grows over neighbours.
+	_, err = l.MoveMany([]uint{4}, 1)
+	r.NoError(err)
+	r.True(l.IsSorted())
+	a.Equal([]string{"l: rebalanced 2 0"}, rec.events)
+
+	rec.events = nil
+	l.ReorderableList = ReorderableList{item(1, "0|a"), item(2, "0|z"), item(3, "0|a00001"), item(4, "0|b")}
+	l.Repair()
+	a.Equal([]string{"l: rebalanced 2 0"}, rec.events, "the misplaced item and the neighbours crowding it")
+}
+
+type observedFetcher struct {
+	*sliceFetcher
+	rec *recorder
+}
+
+func (f observedFetcher) Observer() (string, Observer) { return "fetched", f.rec }
+
+func TestObserver_Neighbours(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	rec := &recorder{}
+	f := observedFetcher{sliceFetcher: &sliceFetcher{}, rec: rec}
+	for i, k := range []string{"0|a", "0|a00001", "0|b"} {
+		f.add(i, mustKey(k))
+	}
+
+	_, changes, err := InsertAfter(context.Background(), f, f.list[0])
+	r.NoError(err)
+	a.Equal([]string{
+		"fetched: between 0|a 0|a00001",
+		fmt.Sprintf("fetched: rebalanced %d 0", len(changes)),
+	}, rec.events)
+}
+
+func TestObserver_OrderedSet(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	rec := &recorder{}
+	s := NewOrderedSet[int]()
+	s.SetObserver("set", rec)
+	r.NoError(s.Insert(mustKey("0|a"), 1))
+	r.NoError(s.Insert(mustKey("0|a00001"), 2))
+
+	_, err := s.InsertAt(1, 3)
+	r.NoError(err)
+	a.Equal([]string{"set: between 0|a 0|a00001", "set: rebalanced 2 0"}, rec.events)
+}
@@ -0,0 +1,445 @@
+package lexorank
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+var ErrDuplicateKey = fmt.Errorf("duplicate key")
+
+// OrderedSet is an in-memory collection of values ordered by Key. Unlike a
+// ReorderableList, which is a plain slice, it's backed by an order-statistic
+// tree so positional lookups, inserts and moves are O(log n) and it's safe to
+// use from multiple goroutines.
+//
+// The tree is persistent: mutations copy the nodes they touch rather than
+// changing them in place. This means readers can take an Iterator, which is a
+// consistent snapshot of the set at that moment, and walk it without holding
+// any locks while writers carry on.
+//
+// Keys are owned by the set. When there is no room for a new key, a small
+// window of neighbours is respaced, growing geometrically until the new key
+// fits.
+type OrderedSet[T any] struct {
+	mu   sync.RWMutex
+	root *treapNode[T]
+	ev   events
+}
+
+func NewOrderedSet[T any]() *OrderedSet[T] {
+	return &OrderedSet[T]{}
+}
+
+// SetObserver reports the set's rebalances to o, tagged with the list ID. A nil
+// Observer reports to the one installed with the package level SetObserver.
+func (s *OrderedSet[T]) SetObserver(list string, o Observer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ev = eventsFor(list, o)
+}
+
+func (s *OrderedSet[T]) events() events {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ev
+}
+
+// Len returns the number of values in the set.
+func (s *OrderedSet[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.root.len()
+}
+
+// Insert adds a value with a specific key, such as when loading a set from
+// storage. If the key is already present, ErrDuplicateKey is returned.
+func (s *OrderedSet[T]) Insert(k Key, v T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	root, _, err := s.root.insert(k, v)
+	if err != nil {
+		return err
+	}
+	s.root = root
+
+	return nil
+}
+
+// Delete removes the value with the given key.
+func (s *OrderedSet[T]) Delete(k Key) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	root, writes, ok := s.root.delete(k)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	s.root = root
+
+	return writes[0].value, true
+}
+
+// Get returns the value with the given key.
+func (s *OrderedSet[T]) Get(k Key) (T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.root.rank(k)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	return s.root.at(i).value, true
+}
+
+// At returns the key and value at position i.
+func (s *OrderedSet[T]) At(i uint) (Key, T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if i >= uint(s.root.len()) {
+		var zero T
+		return Key{}, zero, false
+	}
+
+	n := s.root.at(int(i))
+	return n.key, n.value, true
+}
+
+// Rank returns the position of the given key in the set.
+func (s *OrderedSet[T]) Rank(k Key) (uint, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.root.rank(k)
+	return uint(i), ok
+}
+
+// InsertAt generates a key for a new value so that it's placed at position i,
+// neighbours may be given new keys to make room.
+func (s *OrderedSet[T]) InsertAt(i uint, v T) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	root, k, _, err := s.root.insertAt(i, v, s.ev)
+	if err != nil {
+		return Key{}, err
+	}
+	s.root = root
+
+	return k, nil
+}
+
+// Move takes the value at position from and places it at position to, which is
+// an index into the set as it looks once the value has been taken out. The new
+// key of the moved value is returned.
+func (s *OrderedSet[T]) Move(from, to uint) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	root, k, _, err := s.root.move(from, to, s.ev)
+	if err != nil {
+		return Key{}, err
+	}
+	s.root = root
+
+	return k, nil
+}
+
+// Iter returns an iterator over a snapshot of the whole set.
+func (s *OrderedSet[T]) Iter() *Iterator[T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &Iterator[T]{root: s.root, next: 0, end: s.root.len()}
+}
+
+// Range returns an iterator over a snapshot of every value with a key that is
+// at least lo and less than hi.
+func (s *OrderedSet[T]) Range(lo, hi Key) *Iterator[T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start, _ := s.root.rank(lo)
+	end, _ := s.root.rank(hi)
+
+	return &Iterator[T]{root: s.root, next: start, end: max(start, end)}
+}
+
+// Iterator walks a consistent snapshot of an OrderedSet, it's unaffected by any
+// mutations made after it was created.
+//
+//	it := set.Iter()
+//	for it.Next() {
+//	    fmt.Println(it.Key(), it.Value())
+//	}
+type Iterator[T any] struct {
+	root  *treapNode[T]
+	next  int
+	end   int
+	key   Key
+	value T
+}
+
+// Next advances the iterator, it must be called before the first value is read
+// and returns false once there are no values left.
+func (it *Iterator[T]) Next() bool {
+	if it.next >= it.end {
+		return false
+	}
+
+	n := it.root.at(it.next)
+	it.key, it.value = n.key, n.value
+	it.next++
+
+	return true
+}
+
+func (it *Iterator[T]) Key() Key       { return it.key }
+func (it *Iterator[T]) Value() T       { return it.value }
+func (it *Iterator[T]) Remaining() int { return it.end - it.next }
+
+// setWrite describes a single key being written or removed by a mutation of an
+// OrderedSet, a rekey is represented as a delete of the old key followed by a
+// write of the new one.
+type setWrite[T any] struct {
+	key    Key
+	value  T
+	delete bool
+}
+
+// treapNode is a node of a persistent treap, nodes are never modified once
+// they're reachable from a set's root, they're copied instead.
+type treapNode[T any] struct {
+	key         Key
+	value       T
+	priority    uint64
+	size        int
+	left, right *treapNode[T]
+}
+
+func (n *treapNode[T]) len() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *treapNode[T]) clone() *treapNode[T] {
+	c := *n
+	return &c
+}
+
+func (n *treapNode[T]) update() *treapNode[T] {
+	n.size = 1 + n.left.len() + n.right.len()
+	return n
+}
+
+func merge[T any](a, b *treapNode[T]) *treapNode[T] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	if a.priority > b.priority {
+		c := a.clone()
+		c.right = merge(a.right, b)
+		return c.update()
+	}
+
+	c := b.clone()
+	c.left = merge(a, b.left)
+	return c.update()
+}
+
+// splitKey splits the tree into nodes with keys less than k and the rest.
+func (n *treapNode[T]) splitKey(k Key) (*treapNode[T], *treapNode[T]) {
+	if n == nil {
+		return nil, nil
+	}
+
+	c := n.clone()
+	if n.key.Compare(k) < 0 {
+		l, r := n.right.splitKey(k)
+		c.right = l
+		return c.update(), r
+	}
+
+	l, r := n.left.splitKey(k)
+	c.left = r
+	return l, c.update()
+}
+
+// splitAt splits the tree into the first i nodes and the rest.
+func (n *treapNode[T]) splitAt(i int) (*treapNode[T], *treapNode[T]) {
+	if n == nil {
+		return nil, nil
+	}
+
+	c := n.clone()
+	if i <= n.left.len() {
+		l, r := n.left.splitAt(i)
+		c.left = r
+		return l, c.update()
+	}
+
+	l, r := n.right.splitAt(i - n.left.len() - 1)
+	c.right = l
+	return c.update(), r
+}
+
+func (n *treapNode[T]) at(i int) *treapNode[T] {
+	for n != nil {
+		switch l := n.left.len(); {
+		case i < l:
+			n = n.left
+		case i == l:
+			return n
+		default:
+			i -= l + 1
+			n = n.right
+		}
+	}
+	return nil
+}
+
+// rank returns the number of keys less than k and whether k itself is present.
+func (n *treapNode[T]) rank(k Key) (int, bool) {
+	i := 0
+	found := false
+	for n != nil {
+		switch c := n.key.Compare(k); {
+		case c < 0:
+			i += n.left.len() + 1
+			n = n.right
+		case c == 0:
+			found = true
+			n = n.left
+		default:
+			n = n.left
+		}
+	}
+	return i, found
+}
+
+func leaf[T any](k Key, v T) *treapNode[T] {
+	return &treapNode[T]{key: k, value: v, priority: rand.Uint64(), size: 1}
+}
+
+func (n *treapNode[T]) insert(k Key, v T) (*treapNode[T], []setWrite[T], error) {
+	if _, ok := n.rank(k); ok {
+		return nil, nil, ErrDuplicateKey
+	}
+
+	l, r := n.splitKey(k)
+	return merge(merge(l, leaf(k, v)), r), []setWrite[T]{{key: k, value: v}}, nil
+}
+
+func (n *treapNode[T]) delete(k Key) (*treapNode[T], []setWrite[T], bool) {
+	i, ok := n.rank(k)
+	if !ok {
+		return n, nil, false
+	}
+
+	l, rest := n.splitAt(i)
+	m, r := rest.splitAt(1)
+
+	return merge(l, r), []setWrite[T]{{key: m.key, value: m.value, delete: true}}, true
+}
+
+func (n *treapNode[T]) insertAt(i uint, v T, ev events) (*treapNode[T], Key, []setWrite[T], error) {
+	size := n.len()
+	if i > uint(size) {
+		return nil, Key{}, nil, ErrOutOfBounds
+	}
+
+	var bucket uint8
+	if size > 0 {
+		bucket = n.at(min(int(i), size-1)).key.bucket
+	}
+
+	bounds := func(start, end int) (Key, Key) {
+		lo, hi := BottomOf(bucket), TopOf(bucket)
+		if start > 0 {
+			lo = n.at(start - 1).key
+		}
+		if end < size {
+			hi = n.at(end).key
+		}
+		return lo, hi
+	}
+
+	lo, hi := bounds(int(i), int(i))
+	if k, ok := lo.Between(hi); ok && lo.Compare(*k) < 0 && k.Compare(hi) < 0 {
+		ev.key(*k)
+		root, writes, err := n.insert(*k, v)
+		return root, *k, writes, err
+	}
+	ev.betweenFailed(lo, hi)
+
+	for w := 1; ; w *= 2 {
+		start, end := max(0, int(i)-w), min(size, int(i)+w)
+
+		lo, hi := bounds(start, end)
+		keys, ok := spread(lo, hi, end-start+1)
+		if !ok {
+			if start == 0 && end == size {
+				return nil, Key{}, nil, ErrRebalance
+			}
+			continue
+		}
+
+		left, rest := n.splitAt(start)
+		window, right := rest.splitAt(end - start)
+
+		var middle *treapNode[T]
+		var deletes, puts []setWrite[T]
+		var k Key
+
+		o := 0
+		for j, key := range keys {
+			if start+j == int(i) {
+				k = key
+				middle = merge(middle, leaf(key, v))
+				puts = append(puts, setWrite[T]{key: key, value: v})
+				continue
+			}
+
+			old := window.at(o)
+			o++
+
+			middle = merge(middle, leaf(key, old.value))
+			if old.key.Compare(key) != 0 {
+				deletes = append(deletes, setWrite[T]{key: old.key, value: old.value, delete: true})
+				puts = append(puts, setWrite[T]{key: key, value: old.value})
+			}
+		}
+
+		ev.rebalanced(len(deletes), 0)
+
+		return merge(merge(left, middle), right), k, append(deletes, puts...), nil
+	}
+}
+
+func (n *treapNode[T]) move(from, to uint, ev events) (*treapNode[T], Key, []setWrite[T], error) {
+	size := n.len()
+	if from >= uint(size) || to >= uint(size) {
+		return nil, Key{}, nil, ErrOutOfBounds
+	}
+
+	l, rest := n.splitAt(int(from))
+	m, r := rest.splitAt(1)
+
+	root, k, writes, err := merge(l, r).insertAt(to, m.value, ev)
+	if err != nil {
+		return nil, Key{}, nil, err
+	}
+
+	return root, k, append([]setWrite[T]{{key: m.key, value: m.value, delete: true}}, writes...), nil
+}
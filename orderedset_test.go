@@ -0,0 +1,197 @@
+package lexorank
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect[T any](it *Iterator[T]) ([]Key, []T) {
+	var keys []Key
+	var values []T
+	for it.Next() {
+		keys = append(keys, it.Key())
+		values = append(values, it.Value())
+	}
+	return keys, values
+}
+
+func TestOrderedSet_Basics(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	s := NewOrderedSet[string]()
+	r.NoError(s.Insert(mustKey("0|c"), "c"))
+	r.NoError(s.Insert(mustKey("0|a"), "a"))
+	r.NoError(s.Insert(mustKey("0|b"), "b"))
+	a.Equal(ErrDuplicateKey, s.Insert(mustKey("0|b"), "x"))
+	a.Equal(3, s.Len())
+
+	k, v, ok := s.At(1)
+	a.True(ok)
+	a.Equal("0|b", k.String())
+	a.Equal("b", v)
+
+	_, _, ok = s.At(3)
+	a.False(ok)
+
+	i, ok := s.Rank(mustKey("0|c"))
+	a.True(ok)
+	a.Equal(uint(2), i)
+
+	i, ok = s.Rank(mustKey("0|bU"))
+	a.False(ok)
+	a.Equal(uint(2), i, "rank of a missing key is its insertion index")
+
+	v, ok = s.Get(mustKey("0|a"))
+	a.True(ok)
+	a.Equal("a", v)
+
+	v, ok = s.Delete(mustKey("0|a"))
+	a.True(ok)
+	a.Equal("a", v)
+	_, ok = s.Delete(mustKey("0|a"))
+	a.False(ok)
+
+	_, values := collect(s.Iter())
+	a.Equal([]string{"b", "c"}, values)
+}
+
+func TestOrderedSet_InsertAtMove(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	s := NewOrderedSet[string]()
+
+	_, err := s.InsertAt(0, "b")
+	r.NoError(err)
+	_, err = s.InsertAt(0, "a")
+	r.NoError(err)
+	_, err = s.InsertAt(2, "d")
+	r.NoError(err)
+	k, err := s.InsertAt(2, "c")
+	r.NoError(err)
+
+	i, _ := s.Rank(k)
+	a.Equal(uint(2), i)
+
+	_, values := collect(s.Iter())
+	a.Equal([]string{"a", "b", "c", "d"}, values)
+
+	_, err = s.Move(0, 3)
+	r.NoError(err)
+	_, values = collect(s.Iter())
+	a.Equal([]string{"b", "c", "d", "a"}, values)
+
+	_, err = s.InsertAt(9, "x")
+	a.Equal(ErrOutOfBounds, err)
+	_, err = s.Move(4, 0)
+	a.Equal(ErrOutOfBounds, err)
+}
+
+func TestOrderedSet_Range(t *testing.T) {
+	a := assert.New(t)
+
+	s := NewOrderedSet[string]()
+	for _, k := range []string{"0|a", "0|b", "0|c", "0|d"} {
+		_ = s.Insert(mustKey(k), k)
+	}
+
+	_, values := collect(s.Range(mustKey("0|b"), mustKey("0|d")))
+	a.Equal([]string{"0|b", "0|c"}, values)
+
+	_, values = collect(s.Range(mustKey("0|bU"), mustKey("0|z")))
+	a.Equal([]string{"0|c", "0|d"}, values)
+
+	_, values = collect(s.Range(mustKey("0|d"), mustKey("0|a")))
+	a.Empty(values)
+}
+
+func TestOrderedSet_SnapshotIterator(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	s := NewOrderedSet[int]()
+	for i := range 10 {
+		_, err := s.InsertAt(uint(i), i)
+		r.NoError(err)
+	}
+
+	it := s.Iter()
+	for range 10 {
+		_, err := s.Move(0, 9)
+		r.NoError(err)
+	}
+	_, err := s.InsertAt(0, -1)
+	r.NoError(err)
+
+	a.Equal(10, it.Remaining())
+	_, values := collect(it)
+	a.Equal([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, values)
+}
+
+// TestOrderedSet_MatchesSlice exercises the rebalancing by always inserting in
+// the same place, and checks every operation against a plain slice.
+func TestOrderedSet_MatchesSlice(t *testing.T) {
+	r := require.New(t)
+
+	rng := rand.New(rand.NewSource(7))
+	s := NewOrderedSet[int]()
+	var want []int
+
+	for i := range 2000 {
+		n := len(want)
+		if n > 0 && rng.Intn(4) == 0 {
+			from, to := rng.Intn(n), rng.Intn(n)
+			_, err := s.Move(uint(from), uint(to))
+			r.NoError(err)
+			v := want[from]
+			want = slices.Insert(slices.Delete(want, from, from+1), to, v)
+			continue
+		}
+
+		pos := min(n, 1)
+		_, err := s.InsertAt(uint(pos), i)
+		r.NoError(err)
+		want = slices.Insert(want, pos, i)
+	}
+
+	keys, values := collect(s.Iter())
+	r.Equal(want, values)
+	for i := 1; i < len(keys); i++ {
+		r.True(keys[i-1].Compare(keys[i]) < 0)
+	}
+}
+
+func TestOrderedSet_Concurrent(t *testing.T) {
+	s := NewOrderedSet[string]()
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				_, err := s.InsertAt(uint(i%(s.Len()+1)), fmt.Sprint(w, i))
+				assert.NoError(t, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				keys, _ := collect(s.Iter())
+				for i := 1; i < len(keys); i++ {
+					assert.True(t, keys[i-1].Compare(keys[i]) < 0)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 800, s.Len())
+}
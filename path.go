@@ -0,0 +1,243 @@
+package lexorank
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PathSeparator joins the keys of a Path. It sorts below every character that
+// can appear in a key, which is what makes a parent sort before its children
+// and a whole subtree sort before the next sibling, even when one sibling key
+// is a prefix of another. For example, with siblings "0|a" and "0|aU":
+//
+//	0|a
+//	0|a.0|U
+//	0|aU
+//
+// Since keys are restricted to their own alphabet, a key can never contain the
+// separator and so no escaping is needed. ParsePath validates every segment as
+// a key which guarantees this holds for any Path value.
+const PathSeparator = '.'
+
+// Path is a materialised path made from the keys of every ancestor of a tree
+// node followed by the node's own key, such as "0|a.0|U.0|zz". Ordering paths
+// byte-wise gives a depth-first traversal of the tree with siblings in key
+// order, so an entire tree can be read with a single ORDER BY.
+//
+// The zero value is the empty path, which represents the root of a tree.
+type Path struct {
+	raw  []byte
+	keys Keys
+}
+
+// NewPath builds a path from a list of keys, ordered from the top of the tree
+// down to the node itself.
+func NewPath(keys ...Key) Path {
+	p := Path{keys: make(Keys, len(keys))}
+	copy(p.keys, keys)
+
+	for i, k := range keys {
+		if i > 0 {
+			p.raw = append(p.raw, PathSeparator)
+		}
+		p.raw = append(p.raw, k.raw...)
+	}
+
+	return p
+}
+
+func ParsePath(s string) (*Path, error) {
+	if s == "" {
+		return &Path{}, nil
+	}
+
+	segments := strings.Split(s, string(PathSeparator))
+	keys := make(Keys, len(segments))
+
+	for i, segment := range segments {
+		k, err := ParseKey(segment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path segment %d: %w", i, err)
+		}
+		keys[i] = *k
+	}
+
+	p := NewPath(keys...)
+	return &p, nil
+}
+
+func (p Path) String() string {
+	return string(p.raw)
+}
+
+func (p Path) GoString() string {
+	return string(p.raw)
+}
+
+func (p Path) Compare(o Path) int {
+	return bytes.Compare(p.raw, o.raw)
+}
+
+// Keys returns the keys that make up the path.
+func (p Path) Keys() Keys {
+	keys := make(Keys, len(p.keys))
+	copy(keys, p.keys)
+	return keys
+}
+
+// Key returns the last key of the path, which is the node's own sibling key.
+// The boolean return value is false for the empty path.
+func (p Path) Key() (Key, bool) {
+	if len(p.keys) == 0 {
+		return Key{}, false
+	}
+	return p.keys[len(p.keys)-1], true
+}
+
+// Depth is the number of keys in the path, top level nodes have a depth of 1.
+func (p Path) Depth() int {
+	return len(p.keys)
+}
+
+// Parent returns the path with the last key removed. The boolean return value
+// is false for the empty path, which has no parent.
+func (p Path) Parent() (Path, bool) {
+	if len(p.keys) == 0 {
+		return Path{}, false
+	}
+	return NewPath(p.keys[:len(p.keys)-1]...), true
+}
+
+// Child returns a new path for a child of p with the given key.
+func (p Path) Child(k Key) Path {
+	return NewPath(append(p.Keys(), k)...)
+}
+
+// IsAncestorOf reports whether p is a strict ancestor of o.
+func (p Path) IsAncestorOf(o Path) bool {
+	if len(p.keys) >= len(o.keys) {
+		return false
+	}
+	if len(p.keys) == 0 {
+		return true
+	}
+	return bytes.HasPrefix(o.raw, p.raw) && o.raw[len(p.raw)] == PathSeparator
+}
+
+// Rebase rewrites a path that lives at or underneath from so that it lives at
+// or underneath to instead. The boolean return value is false if p is not part
+// of the subtree rooted at from, in which case p is returned as-is.
+func (p Path) Rebase(from, to Path) (Path, bool) {
+	if p.Compare(from) != 0 && !from.IsAncestorOf(p) {
+		return p, false
+	}
+	return NewPath(append(to.Keys(), p.keys[len(from.keys):]...)...), true
+}
+
+// RebasePaths rewrites every path in the subtree rooted at from after that
+// subtree has been moved to to. Paths outside the subtree are left alone. The
+// number of paths that were rewritten is returned.
+//
+// This is what you'd run over the results of a query such as:
+//
+//	SELECT id, path FROM nodes WHERE path = $1 OR path LIKE $1 || '.%'
+func RebasePaths(paths []Path, from, to Path) int {
+	n := 0
+	for i, p := range paths {
+		if rebased, ok := p.Rebase(from, to); ok {
+			paths[i] = rebased
+			n++
+		}
+	}
+	return n
+}
+
+// Path builds the materialised path of a node from the keys of its ancestors.
+func (t *Tree[T]) Path(id T) (Path, bool) {
+	n, ok := t.nodes[id]
+	if !ok {
+		return Path{}, false
+	}
+
+	var keys Keys
+	for ; n != t.root; n = n.parent {
+		keys = append(Keys{n.key}, keys...)
+	}
+
+	return NewPath(keys...), true
+}
+
+var (
+	_ encoding.TextMarshaler   = (*Path)(nil)
+	_ encoding.TextUnmarshaler = (*Path)(nil)
+	_ json.Marshaler           = (*Path)(nil)
+	_ json.Unmarshaler         = (*Path)(nil)
+	_ driver.Valuer            = (*Path)(nil)
+	_ sql.Scanner              = (*Path)(nil)
+)
+
+// TextMarshaler
+func (p Path) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// TextUnmarshaler
+func (p *Path) UnmarshalText(text []byte) error {
+	parsed, err := ParsePath(string(text))
+	if err != nil {
+		return err
+	}
+	*p = *parsed
+	return nil
+}
+
+// JSON Marshaler
+func (p Path) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// JSON Unmarshaler
+func (p *Path) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParsePath(s)
+	if err != nil {
+		return err
+	}
+	*p = *parsed
+	return nil
+}
+
+// SQL Valuer
+func (p Path) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// SQL Scanner
+func (p *Path) Scan(value any) error {
+	switch v := value.(type) {
+	case string:
+		parsed, err := ParsePath(v)
+		if err != nil {
+			return err
+		}
+		*p = *parsed
+		return nil
+	case []byte:
+		parsed, err := ParsePath(string(v))
+		if err != nil {
+			return err
+		}
+		*p = *parsed
+		return nil
+	default:
+		return fmt.Errorf("cannot scan type %T into Path", value)
+	}
+}
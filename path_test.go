@@ -0,0 +1,171 @@
+package lexorank
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustPath(s string) Path {
+	p, err := ParsePath(s)
+	if err != nil {
+		panic(err)
+	}
+	return *p
+}
+
+func TestPath_Parse(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	p, err := ParsePath("0|a.0|U.0|zz")
+	r.NoError(err)
+	a.Equal("0|a.0|U.0|zz", p.String())
+	a.Equal(3, p.Depth())
+
+	k, ok := p.Key()
+	a.True(ok)
+	a.Equal("0|zz", k.String())
+
+	empty, err := ParsePath("")
+	r.NoError(err)
+	a.Equal(0, empty.Depth())
+
+	_, err = ParsePath("0|a..0|b")
+	a.Error(err)
+
+	_, err = ParsePath("0|a.0|a~")
+	a.Error(err)
+}
+
+func TestPath_DepthFirstOrder(t *testing.T) {
+	a := assert.New(t)
+
+	// Deliberately includes a sibling key that is a prefix of another.
+	want := []string{
+		"0|a",
+		"0|a.0|0",
+		"0|a.0|U",
+		"0|a.0|U.0|zz",
+		"0|a.0|UU",
+		"0|aU",
+		"0|aU.0|a",
+		"0|b",
+	}
+
+	shuffled := []string{want[5], want[2], want[7], want[0], want[4], want[6], want[1], want[3]}
+	paths := make([]Path, len(shuffled))
+	for i, s := range shuffled {
+		paths[i] = mustPath(s)
+	}
+
+	sort.Slice(paths, func(i, j int) bool { return paths[i].Compare(paths[j]) < 0 })
+
+	got := make([]string, len(paths))
+	for i, p := range paths {
+		got[i] = p.String()
+	}
+	a.Equal(want, got)
+
+	// Plain string ordering, as a database would use, must agree.
+	a.True(sort.StringsAreSorted(got))
+}
+
+func TestPath_Parent(t *testing.T) {
+	a := assert.New(t)
+
+	p := mustPath("0|a.0|U.0|zz")
+
+	parent, ok := p.Parent()
+	a.True(ok)
+	a.Equal("0|a.0|U", parent.String())
+
+	a.True(parent.IsAncestorOf(p))
+	a.True(mustPath("").IsAncestorOf(p))
+	a.False(p.IsAncestorOf(parent))
+	a.False(p.IsAncestorOf(p))
+	a.False(mustPath("0|a.0|U").IsAncestorOf(mustPath("0|a.0|UU.0|a")))
+
+	top, _ := mustPath("0|a").Parent()
+	a.Equal(0, top.Depth())
+
+	_, ok = top.Parent()
+	a.False(ok)
+
+	a.Equal("0|a.0|U.0|zz.0|b", p.Child(mustKey("0|b")).String())
+}
+
+func TestPath_Rebase(t *testing.T) {
+	a := assert.New(t)
+
+	paths := []Path{
+		mustPath("0|a"),
+		mustPath("0|a.0|U"),
+		mustPath("0|a.0|U.0|zz"),
+		mustPath("0|a.0|UU"),
+		mustPath("0|b"),
+	}
+
+	n := RebasePaths(paths, mustPath("0|a.0|U"), mustPath("0|b.0|c"))
+	a.Equal(2, n)
+
+	a.Equal("0|a", paths[0].String())
+	a.Equal("0|b.0|c", paths[1].String())
+	a.Equal("0|b.0|c.0|zz", paths[2].String())
+	a.Equal("0|a.0|UU", paths[3].String())
+	a.Equal("0|b", paths[4].String())
+}
+
+func TestTree_Path(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	tree := testTree(t)
+
+	p, ok := tree.Path("a2")
+	r.True(ok)
+	a.Equal("0|a.0|U", p.String())
+
+	before, _ := tree.Path("a")
+
+	_, err := tree.MoveNode("a", "b", 1)
+	r.NoError(err)
+
+	after, _ := tree.Path("a")
+	moved, ok := mustPath("0|a.0|U").Rebase(before, after)
+	a.True(ok)
+
+	want, _ := tree.Path("a2")
+	a.Equal(want.String(), moved.String())
+}
+
+func TestPath_Marshalling(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	orig := mustPath("0|a.0|U")
+
+	data, err := json.Marshal(orig)
+	r.NoError(err)
+	a.Equal(`"0|a.0|U"`, string(data))
+
+	var out Path
+	r.NoError(json.Unmarshal(data, &out))
+	a.Equal(0, orig.Compare(out))
+
+	text, err := orig.MarshalText()
+	r.NoError(err)
+	r.NoError(out.UnmarshalText(text))
+	a.Equal(0, orig.Compare(out))
+
+	val, err := orig.Value()
+	r.NoError(err)
+	a.Equal("0|a.0|U", val)
+
+	r.NoError(out.Scan([]byte("0|b")))
+	a.Equal("0|b", out.String())
+	a.Error(out.Scan(123))
+}
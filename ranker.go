@@ -19,6 +19,17 @@ type Reorderable interface {
 	Mutable
 }
 
+// Change records a single item whose key was rewritten by a list operation.
+type Change struct {
+	Item Reorderable
+	Old  Key
+	New  Key
+}
+
+// Changes is the set of items touched by an operation, this is usually what you
+// want to write back to your storage rather than the whole list.
+type Changes []Change
+
 // ReorderableList represents a collection of orderable items, usually from a
 // database. It's designed so that you read a range of items from your storage
 // that you wish to apply one or more re-order operations to before saving them
@@ -41,37 +52,66 @@ func (a ReorderableList) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ReorderableList) Less(i, j int) bool { return a[i].GetKey().String() < a[j].GetKey().String() }
 
 func (l ReorderableList) Insert(position uint) (*Key, error) {
+	return l.insert(position, events{})
+}
+
+func (l ReorderableList) insert(position uint, ev events) (*Key, error) {
 	if position > uint(len(l)) {
 		return nil, ErrOutOfBounds
 	}
 
 	if position == 0 {
-		k := l.Prepend()
+		k := l.prepend(ev)
 		return &k, nil
 	}
 
 	if position == uint(len(l)) {
-		k := l.Append()
+		k := l.append(ev)
 		return &k, nil
 	}
 
 	prev := l[position-1].GetKey()
 	next := l[position].GetKey()
 
-	for range 2 {
+	// Try the gap, rebalancing at most twice and trying again after each one.
+	for attempt := 0; ; attempt++ {
 		k, ok := prev.Between(next)
 		if ok {
+			ev.key(*k)
 			return k, nil
 		}
+		ev.betweenFailed(prev, next)
+
+		if attempt == 2 {
+			break
+		}
 
-		l.rebalanceFrom(position, 1)
+		// Make room by pulling the item before the gap backwards, this widens
+		// the gap we're actually inserting into.
+		l.rebalanceFrom(position-1, -1, ev)
 
 		// refresh prev/next keys
 		prev = l[position-1].GetKey()
 		next = l[position].GetKey()
 	}
 
-	return nil, fmt.Errorf("failed to insert key after rebalance")
+	return nil, fmt.Errorf("failed to insert key after rebalance: %w", ErrRebalance)
+}
+
+// InsertIn is Insert for lists that may be empty, such as the children of a
+// tree node. The first item of an empty list is given the middle key of the
+// bucket so there's room either side of it, where Insert would give it Top and
+// leave nothing after it.
+func (l ReorderableList) InsertIn(bucket uint8, position uint) (*Key, error) {
+	return l.insertIn(bucket, position, events{})
+}
+
+func (l ReorderableList) insertIn(bucket uint8, position uint, ev events) (*Key, error) {
+	if len(l) == 0 && position == 0 {
+		k := MiddleOf(bucket)
+		return &k, nil
+	}
+	return l.insert(position, ev)
 }
 
 // Append does not change the size of the underlying list, but it may rebalance
@@ -80,6 +120,10 @@ func (l ReorderableList) Insert(position uint) (*Key, error) {
 // In a worst case scenario, if the list already has a key at the maximum index,
 // the list is rebalanced to make space at the end for the new generated key.
 func (l ReorderableList) Append() Key {
+	return l.append(events{})
+}
+
+func (l ReorderableList) append(ev events) Key {
 	if len(l) == 0 {
 		return Bottom
 	}
@@ -88,10 +132,12 @@ func (l ReorderableList) Append() Key {
 		last := l[len(l)-1].GetKey()
 		k, ok := last.Between(TopOf(last.bucket))
 		if ok {
+			ev.key(*k)
 			return *k
 		}
+		ev.betweenFailed(last, TopOf(last.bucket))
 
-		l.rebalanceFrom(uint(len(l)-1), -1)
+		l.rebalanceFrom(uint(len(l)-1), -1, ev)
 	}
 
 	panic("failed to append key after rebalance")
@@ -102,6 +148,10 @@ func (l ReorderableList) Append() Key {
 //
 // Same worst case scenario as Append.
 func (l ReorderableList) Prepend() Key {
+	return l.prepend(events{})
+}
+
+func (l ReorderableList) prepend(ev events) Key {
 	if len(l) == 0 {
 		return Top
 	}
@@ -110,17 +160,20 @@ func (l ReorderableList) Prepend() Key {
 		first := l[0].GetKey()
 		k, ok := BottomOf(first.bucket).Between(first)
 		if ok {
+			ev.key(*k)
 			return *k
 		}
+		ev.betweenFailed(BottomOf(first.bucket), first)
 
-		l.rebalanceFrom(0, 1)
+		l.rebalanceFrom(0, 1, ev)
 	}
 
 	panic("failed to prepend key after rebalance")
 }
 
-func (l ReorderableList) rebalanceFrom(position uint, direction int) {
-	ok := l.tryRebalanceFrom(position, direction)
+func (l ReorderableList) rebalanceFrom(position uint, direction int, ev events) {
+	touched, ok := l.tryRebalanceFrom(position, direction)
+	ev.rebalanced(touched, direction)
 	if ok {
 		return
 	}
@@ -128,17 +181,21 @@ func (l ReorderableList) rebalanceFrom(position uint, direction int) {
 	// If we're here, the worst case scenario was reached: every key is adjacent
 	// to the next one. We need to normalise the entire list.
 
-	l.Normalise()
+	l.normalise(true, ev)
 }
 
-func (l ReorderableList) tryRebalanceFrom(position uint, direction int) bool {
+// tryRebalanceFrom returns the number of items it rewrote and whether it made
+// room at position.
+func (l ReorderableList) tryRebalanceFrom(position uint, direction int) (int, bool) {
 	if direction > 0 && position >= uint(len(l)-1) {
-		return false // at end of list
+		return 0, false // at end of list
 	}
 	if direction < 0 && position == 0 {
-		return false // at start of list
+		return 0, false // at start of list
 	}
 
+	touched := 0
+
 	if direction > 0 {
 		for i := int(position); i < len(l)-1; i++ {
 			curr := l[i].GetKey()
@@ -147,9 +204,10 @@ func (l ReorderableList) tryRebalanceFrom(position uint, direction int) bool {
 			nextKey, ok := curr.Between(next)
 			if ok {
 				l[i+1].SetKey(*nextKey)
+				touched++
 				if i == int(position) {
 					// first pass worked, can exit early.
-					return true
+					return touched, true
 				}
 			}
 
@@ -163,9 +221,10 @@ func (l ReorderableList) tryRebalanceFrom(position uint, direction int) bool {
 			nextKey, ok := next.Between(curr)
 			if ok {
 				l[i].SetKey(*nextKey)
+				touched++
 				if i == int(position) {
 					// first pass worked, can exit early.
-					return true
+					return touched, true
 				}
 			}
 
@@ -173,17 +232,26 @@ func (l ReorderableList) tryRebalanceFrom(position uint, direction int) bool {
 		}
 	}
 
-	return false
+	return touched, false
 }
 
 // Normalise will distribute the keys evenly across the key space.
 func (l ReorderableList) Normalise() {
+	l.normalise(false, events{})
+}
+
+// normalise is Normalise, forced is reported to the Observer to tell apart
+// explicit normalisations from fallbacks when a list has run out of room.
+func (l ReorderableList) normalise(forced bool, ev events) {
 	for i := 0; i < len(l); i++ {
-		f := float64(i+2) / float64(len(l)+3)
-		b := l[i].GetKey().bucket
-		nextKey := KeyAt(b, f)
-		l[i].SetKey(nextKey)
+		l[i].SetKey(normaliseKey(l[i].GetKey().bucket, i, len(l)))
 	}
+	ev.normalised(len(l), forced)
+}
+
+// normaliseKey is the key for index i of a normalised list of n items.
+func normaliseKey(bucket uint8, i, n int) Key {
+	return KeyAt(bucket, float64(i+2)/float64(n+3))
 }
 
 func (l ReorderableList) IsSorted() bool {
@@ -194,3 +262,24 @@ func (l ReorderableList) IsSorted() bool {
 	}
 	return true
 }
+
+// keys captures the current key of every item so changes can be diffed later.
+func (l ReorderableList) keys() Keys {
+	keys := make(Keys, len(l))
+	for i, item := range l {
+		keys[i] = item.GetKey()
+	}
+	return keys
+}
+
+// changes compares the list against keys captured with keys() and returns the
+// items whose key differs.
+func (l ReorderableList) changes(before Keys) Changes {
+	var changes Changes
+	for i, item := range l {
+		if k := item.GetKey(); k.Compare(before[i]) != 0 {
+			changes = append(changes, Change{Item: item, Old: before[i], New: k})
+		}
+	}
+	return changes
+}
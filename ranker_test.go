@@ -1,6 +1,7 @@
 package lexorank
 
 import (
+	"math/rand/v2"
 	"sort"
 	"testing"
 
@@ -43,7 +44,7 @@ func TestReorderableList_Rebalance(t *testing.T) {
 	}
 	a.Equal(original, data)
 
-	data.rebalanceFrom(0, 1)
+	data.rebalanceFrom(0, 1, events{})
 
 	a.NotEqual(original, data)
 	a.True(sort.IsSorted(data))
@@ -261,7 +262,7 @@ func TestReorderableList_BackwardRebalanceLogic(t *testing.T) {
 		item(4, "1|aaaaae"),
 		item(5, "1|aaaaaf"),
 	}
-	list.rebalanceFrom(5, -1)
+	list.rebalanceFrom(5, -1, events{})
 
 	a.True(sort.IsSorted(list), "list should be sorted after backward rebalance")
 }
@@ -279,7 +280,7 @@ func TestTryRebalanceFrom_BackwardFailsWithWrongBetweenOrder(t *testing.T) {
 	}
 
 	// We intentionally call tryRebalanceFrom on index 1, going backward (-1)
-	ok := list.tryRebalanceFrom(1, -1)
+	_, ok := list.tryRebalanceFrom(1, -1)
 	a.True(ok, "should succeed if Between() arg order is correct")
 
 	// If successful, keys should still be sorted
@@ -297,7 +298,7 @@ func TestTryRebalanceFrom_ForwardFirstPassSucceeds(t *testing.T) {
 		&Item{ID: 1, Rank: *mid},
 	}
 
-	ok := list.tryRebalanceFrom(0, 1)
+	_, ok := list.tryRebalanceFrom(0, 1)
 	a.True(ok, "expected forward rebalance to succeed on first pass")
 	a.True(sort.IsSorted(list), "list should still be sorted")
 	a.NotEqual(mid.String(), list[1].GetKey().String(), "key should have changed during rebalance")
@@ -325,3 +326,48 @@ func item(id int, s string) Reorderable {
 	}
 	return &Item{ID: id, Rank: *o}
 }
+
+func TestInsert_RebalancesTheTargetGap(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	list := ReorderableList{
+		item(0, "1|a"),
+		item(1, "1|aaaaaa"),
+		item(2, "1|aaaaab"),
+		item(3, "1|z"),
+	}
+
+	newKey, err := list.Insert(2)
+	r.NoError(err)
+
+	a.True(newKey.Compare(list[1].GetKey()) > 0)
+	a.True(newKey.Compare(list[2].GetKey()) < 0)
+	a.Equal("1|aaaaab", list[2].GetKey().String(), "only the item before the gap moves")
+	a.True(list.IsSorted())
+}
+
+func TestInsert_CrowdedLists(t *testing.T) {
+	r := require.New(t)
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	// Lists of keys only a step or two apart, so most inserts need at least
+	// one rebalance and some need a second one before the gap has room.
+	for range 5000 {
+		n := 2 + rng.IntN(30)
+		p := rng.Int64N(int64(maxValue) - 1000)
+
+		list := make(ReorderableList, n)
+		for i := range list {
+			p += rng.Int64N(3) + 1
+			list[i] = &Item{ID: i, Rank: keyAtPosition(0, p)}
+		}
+
+		position := uint(1 + rng.IntN(n-1))
+		k, err := list.Insert(position)
+		r.NoError(err)
+		r.True(list.IsSorted())
+		r.True(list[position-1].GetKey().Compare(*k) < 0)
+		r.True(k.Compare(list[position].GetKey()) < 0)
+	}
+}
@@ -0,0 +1,259 @@
+package lexorank
+
+import (
+	"slices"
+	"sort"
+)
+
+// ReplicaOp is a single insert, move or remove recorded by a Replica. It is the
+// unit that replicas exchange in order to converge. Each operation is uniquely
+// identified by the site that created it and that site's logical clock.
+type ReplicaOp struct {
+	Kind  OperationKind `json:"kind"`
+	ID    string        `json:"id"`
+	Key   *Key          `json:"key,omitempty"`
+	Site  string        `json:"site"`
+	Clock uint64        `json:"clock"`
+}
+
+func (op ReplicaOp) newer(clock uint64, site string) bool {
+	if op.Clock != clock {
+		return op.Clock > clock
+	}
+	return op.Site > site
+}
+
+type replicaElement struct {
+	id      string
+	key     Key
+	site    string
+	clock   uint64
+	placed  bool
+	removed bool
+}
+
+func (e *replicaElement) less(o *replicaElement) bool {
+	if c := e.key.Compare(o.key); c != 0 {
+		return c < 0
+	}
+	if e.site != o.site {
+		return e.site < o.site
+	}
+	return e.id < o.id
+}
+
+type replicaOpID struct {
+	site  string
+	clock uint64
+}
+
+// Replica is a sequence CRDT built on lexorank keys, in the spirit of Logoot
+// and LSEQ. Each replica edits its own copy of a list, such as on a device that
+// is offline, and exchanges operations with other replicas. Once every replica
+// has seen the same set of operations, they all have the same order regardless
+// of the order operations arrived in or how many times they were delivered.
+//
+// Elements are ordered by key with the ID of the site that placed them as a
+// tiebreaker, so two sites generating the same key for the same gap still
+// agree on an order. Moves are last-writer-wins using a Lamport clock and
+// removes always win over concurrent inserts and moves.
+//
+// Unlike a ReorderableList, a replica never rebalances its neighbours as a side
+// effect of an insert, since other replicas would not know about it. When there
+// is no room left in a gap, or two sites tied on the same key, ErrRebalance is
+// returned. Normalise re-keys the list with ordinary moves that are replicated
+// like any other, after which the insert can be retried.
+type Replica struct {
+	site     string
+	clock    uint64
+	elements map[string]*replicaElement
+	seen     map[replicaOpID]bool
+	log      []ReplicaOp
+}
+
+func NewReplica(site string) *Replica {
+	return &Replica{
+		site:     site,
+		elements: map[string]*replicaElement{},
+		seen:     map[replicaOpID]bool{},
+	}
+}
+
+// Site returns the identifier of this replica.
+func (r *Replica) Site() string { return r.site }
+
+// Order returns the IDs of every element that has not been removed, in order.
+func (r *Replica) Order() []string {
+	visible := r.visible()
+	ids := make([]string, len(visible))
+	for i, e := range visible {
+		ids[i] = e.id
+	}
+	return ids
+}
+
+// Key returns the current key of an element.
+func (r *Replica) Key(id string) (Key, bool) {
+	e, ok := r.elements[id]
+	if !ok || !e.placed || e.removed {
+		return Key{}, false
+	}
+	return e.key, true
+}
+
+// Ops returns every operation this replica knows about, both local and remote,
+// which is what should be sent to other replicas.
+func (r *Replica) Ops() []ReplicaOp {
+	return slices.Clone(r.log)
+}
+
+// Insert places a new element at the given position.
+func (r *Replica) Insert(id string, position uint) (ReplicaOp, error) {
+	if _, ok := r.elements[id]; ok {
+		return ReplicaOp{}, ErrEntryExists
+	}
+
+	k, err := r.keyAt(r.visible(), position)
+	if err != nil {
+		return ReplicaOp{}, err
+	}
+
+	return r.local(ReplicaOp{Kind: OperationInsert, ID: id, Key: k}), nil
+}
+
+// Move places an existing element at the given position, which is an index
+// into the list as it looks once the element has been taken out.
+func (r *Replica) Move(id string, position uint) (ReplicaOp, error) {
+	e, ok := r.elements[id]
+	if !ok || !e.placed || e.removed {
+		return ReplicaOp{}, ErrItemNotFound
+	}
+
+	visible := slices.DeleteFunc(r.visible(), func(v *replicaElement) bool { return v == e })
+
+	k, err := r.keyAt(visible, position)
+	if err != nil {
+		return ReplicaOp{}, err
+	}
+
+	return r.local(ReplicaOp{Kind: OperationMove, ID: id, Key: k}), nil
+}
+
+// Normalise evenly redistributes the keys of every element, in the current
+// order, and returns the moves it made. The moves merge like any other, so a
+// concurrent edit on another replica may land in a different place relative to
+// the new keys, but every replica still converges on the same order.
+func (r *Replica) Normalise() []ReplicaOp {
+	visible := r.visible()
+
+	var ops []ReplicaOp
+	for i, e := range visible {
+		k := normaliseKey(e.key.bucket, i, len(visible))
+		if k.Compare(e.key) == 0 {
+			continue
+		}
+		ops = append(ops, r.local(ReplicaOp{Kind: OperationMove, ID: e.id, Key: &k}))
+	}
+
+	return ops
+}
+
+// Remove deletes an element. Removes are permanent, an element that has been
+// removed can never be placed again.
+func (r *Replica) Remove(id string) (ReplicaOp, error) {
+	e, ok := r.elements[id]
+	if !ok || !e.placed || e.removed {
+		return ReplicaOp{}, ErrItemNotFound
+	}
+
+	return r.local(ReplicaOp{Kind: OperationRemove, ID: id}), nil
+}
+
+// Merge applies operations received from another replica. Operations that have
+// already been applied are ignored.
+func (r *Replica) Merge(ops []ReplicaOp) {
+	for _, op := range ops {
+		r.apply(op)
+	}
+}
+
+func (r *Replica) local(op ReplicaOp) ReplicaOp {
+	op.Site = r.site
+	op.Clock = r.clock + 1
+	r.apply(op)
+	return op
+}
+
+func (r *Replica) apply(op ReplicaOp) {
+	id := replicaOpID{site: op.Site, clock: op.Clock}
+	if r.seen[id] {
+		return
+	}
+	r.seen[id] = true
+	r.log = append(r.log, op)
+	r.clock = max(r.clock, op.Clock)
+
+	e, ok := r.elements[op.ID]
+	if !ok {
+		e = &replicaElement{id: op.ID}
+		r.elements[op.ID] = e
+	}
+
+	switch op.Kind {
+	case OperationInsert, OperationMove:
+		if op.Key == nil {
+			return
+		}
+		if !e.placed || op.newer(e.clock, e.site) {
+			e.key = *op.Key
+			e.site = op.Site
+			e.clock = op.Clock
+			e.placed = true
+		}
+
+	case OperationRemove:
+		e.removed = true
+	}
+}
+
+func (r *Replica) visible() []*replicaElement {
+	visible := make([]*replicaElement, 0, len(r.elements))
+	for _, e := range r.elements {
+		if e.placed && !e.removed {
+			visible = append(visible, e)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool { return visible[i].less(visible[j]) })
+	return visible
+}
+
+func (r *Replica) keyAt(visible []*replicaElement, position uint) (*Key, error) {
+	if position > uint(len(visible)) {
+		return nil, ErrOutOfBounds
+	}
+
+	lo := Bottom
+	if position > 0 {
+		lo = visible[position-1].key
+	}
+
+	hi := Top
+	if position < uint(len(visible)) {
+		hi = visible[position].key
+	}
+
+	if len(visible) > 0 {
+		if position == 0 {
+			lo = BottomOf(hi.bucket)
+		} else if position == uint(len(visible)) {
+			hi = TopOf(lo.bucket)
+		}
+	}
+
+	k, ok := lo.Between(hi)
+	if !ok || k.Compare(lo) <= 0 || k.Compare(hi) >= 0 {
+		return nil, ErrRebalance
+	}
+
+	return k, nil
+}
@@ -0,0 +1,192 @@
+package lexorank
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplica_Local(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	rep := NewReplica("a")
+
+	_, err := rep.Insert("x", 0)
+	r.NoError(err)
+	_, err = rep.Insert("z", 1)
+	r.NoError(err)
+	_, err = rep.Insert("y", 1)
+	r.NoError(err)
+	a.Equal([]string{"x", "y", "z"}, rep.Order())
+
+	_, err = rep.Move("x", 2)
+	r.NoError(err)
+	a.Equal([]string{"y", "z", "x"}, rep.Order())
+
+	_, err = rep.Remove("z")
+	r.NoError(err)
+	a.Equal([]string{"y", "x"}, rep.Order())
+
+	_, err = rep.Insert("y", 0)
+	a.Equal(ErrEntryExists, err)
+	_, err = rep.Move("z", 0)
+	a.Equal(ErrItemNotFound, err)
+	_, err = rep.Insert("w", 5)
+	a.Equal(ErrOutOfBounds, err)
+}
+
+func TestReplica_ConcurrentSameGap(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	left := NewReplica("left")
+	right := NewReplica("right")
+
+	_, err := left.Insert("a", 0)
+	r.NoError(err)
+	_, err = left.Insert("b", 1)
+	r.NoError(err)
+	right.Merge(left.Ops())
+
+	// Both sites insert into the same gap and so generate the same key.
+	l, err := left.Insert("from-left", 1)
+	r.NoError(err)
+	rr, err := right.Insert("from-right", 1)
+	r.NoError(err)
+	a.Equal(l.Key.String(), rr.Key.String())
+
+	left.Merge(right.Ops())
+	right.Merge(left.Ops())
+
+	a.Equal([]string{"a", "from-left", "from-right", "b"}, left.Order())
+	a.Equal(left.Order(), right.Order())
+
+	// The tie can't be split without re-keying the list.
+	_, err = left.Insert("c", 2)
+	a.Equal(ErrRebalance, err)
+
+	right.Merge(left.Normalise())
+	_, err = left.Insert("c", 2)
+	r.NoError(err)
+	right.Merge(left.Ops())
+
+	a.Equal([]string{"a", "from-left", "c", "from-right", "b"}, left.Order())
+	a.Equal(left.Order(), right.Order())
+}
+
+func TestReplica_RemoveWins(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	left := NewReplica("left")
+	right := NewReplica("right")
+
+	_, err := left.Insert("a", 0)
+	r.NoError(err)
+	_, err = left.Insert("b", 1)
+	r.NoError(err)
+	right.Merge(left.Ops())
+
+	_, err = left.Remove("a")
+	r.NoError(err)
+	_, err = right.Move("a", 1)
+	r.NoError(err)
+
+	left.Merge(right.Ops())
+	right.Merge(left.Ops())
+
+	a.Equal([]string{"b"}, left.Order())
+	a.Equal([]string{"b"}, right.Order())
+}
+
+func TestReplica_OpsJSON(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	src := NewReplica("a")
+	_, err := src.Insert("x", 0)
+	r.NoError(err)
+	_, err = src.Remove("x")
+	r.NoError(err)
+
+	data, err := json.Marshal(src.Ops())
+	r.NoError(err)
+
+	var ops []ReplicaOp
+	r.NoError(json.Unmarshal(data, &ops))
+
+	dst := NewReplica("b")
+	dst.Merge(ops)
+	a.Empty(dst.Order())
+	a.Len(dst.Ops(), 2)
+}
+
+// TestReplica_Convergence simulates several replicas making random concurrent
+// edits and exchanging operations over an unreliable network that reorders and
+// duplicates them. Once everything has been delivered, every replica must end
+// up with exactly the same order.
+func TestReplica_Convergence(t *testing.T) {
+	for seed := range int64(20) {
+		t.Run(fmt.Sprint(seed), func(t *testing.T) {
+			simulate(t, rand.New(rand.NewSource(seed)), 5, 400)
+		})
+	}
+}
+
+func simulate(t *testing.T, rng *rand.Rand, sites, steps int) {
+	replicas := make([]*Replica, sites)
+	for i := range replicas {
+		replicas[i] = NewReplica(fmt.Sprintf("site-%d", i))
+	}
+
+	next := 0
+	for range steps {
+		rep := replicas[rng.Intn(len(replicas))]
+		order := rep.Order()
+
+		switch n := len(order); {
+		case n > 0 && rng.Intn(4) == 0:
+			_, _ = rep.Move(order[rng.Intn(n)], uint(rng.Intn(n)))
+		case n > 0 && rng.Intn(6) == 0:
+			_, _ = rep.Remove(order[rng.Intn(n)])
+		case rng.Intn(8) == 0:
+			// Partial, out of order and duplicated delivery from another site.
+			from := replicas[rng.Intn(len(replicas))].Ops()
+			rng.Shuffle(len(from), func(i, j int) { from[i], from[j] = from[j], from[i] })
+			from = from[:rng.Intn(len(from)+1)]
+			rep.Merge(append(from, from...))
+		default:
+			// When a gap is exhausted the list is re-keyed, concurrently with
+			// whatever the other sites are doing, and the insert retried.
+			position := uint(rng.Intn(n + 1))
+			if _, err := rep.Insert(fmt.Sprint(next), position); err == ErrRebalance {
+				rep.Normalise()
+				_, err = rep.Insert(fmt.Sprint(next), position)
+				require.NoError(t, err)
+			}
+			next++
+		}
+	}
+
+	all := []ReplicaOp{}
+	for _, rep := range replicas {
+		all = append(all, rep.Ops()...)
+	}
+
+	for _, rep := range replicas {
+		ops := append([]ReplicaOp{}, all...)
+		rng.Shuffle(len(ops), func(i, j int) { ops[i], ops[j] = ops[j], ops[i] })
+		rep.Merge(ops)
+	}
+
+	want := replicas[0].Order()
+	require.NotEmpty(t, want)
+	for _, rep := range replicas[1:] {
+		require.Equal(t, want, rep.Order(), "replica %s diverged", rep.Site())
+	}
+}
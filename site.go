@@ -0,0 +1,71 @@
+package lexorank
+
+// Site generates keys that are unique to a single client or node. Between is
+// deterministic, so two clients that pick the same gap at the same time will
+// compute the same key and their items will tie. A site appends its own short
+// suffix to every key it generates, so keys from different sites can never be
+// equal while still sorting between the same neighbours.
+//
+// For example, two sites inserting between "0|a" and "0|b" produce "0|aU/1"
+// and "0|aU/2" which are distinct and both sort after "0|a" and before "0|b".
+type Site struct {
+	suffix []byte
+}
+
+// NewSite creates a site from a numeric client or node identifier, which is
+// encoded into the key alphabet. Different identifiers always produce
+// different suffixes.
+func NewSite(id uint32) Site {
+	return Site{suffix: encodeBase75(int64(id))}
+}
+
+// ParseSite creates a site from a suffix that has already been encoded, such as
+// one read back from Key.Suffix.
+func ParseSite(suffix string) (Site, error) {
+	if err := validateSuffix([]byte(suffix)); err != nil {
+		return Site{}, err
+	}
+	return Site{suffix: []byte(suffix)}, nil
+}
+
+func (s Site) String() string {
+	return string(s.suffix)
+}
+
+// Between works the same as Key.Between but stamps the resulting key with the
+// site's suffix.
+func (s Site) Between(from, to Key) (*Key, bool) {
+	if from.Compare(to) > 0 {
+		from, to = to, from
+	}
+
+	k, ok := from.Between(to)
+	if !ok {
+		return nil, false
+	}
+
+	return s.stamp(*k, to)
+}
+
+// stamp appends the site's suffix to a freshly generated key, which must not
+// already have one. The suffix makes the key sort slightly later, so it's
+// checked against the upper bound again.
+func (s Site) stamp(k Key, to Key) (*Key, bool) {
+	raw := make([]byte, 0, len(k.raw)+1+len(s.suffix))
+	raw = append(raw, k.raw...)
+	raw = append(raw, SuffixSeparator)
+	raw = append(raw, s.suffix...)
+
+	stamped := &Key{
+		raw:    raw,
+		rank:   k.rank,
+		suffix: s.suffix,
+		bucket: k.bucket,
+	}
+
+	if stamped.Compare(to) >= 0 {
+		return nil, false
+	}
+
+	return stamped, true
+}
@@ -0,0 +1,101 @@
+package lexorank
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSite_Between(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	lo := mustKey("0|a")
+	hi := mustKey("0|b")
+
+	one := NewSite(1)
+	two := NewSite(2)
+
+	k1, ok := one.Between(lo, hi)
+	r.True(ok)
+	k2, ok := two.Between(hi, lo)
+	r.True(ok)
+
+	a.Equal("0|aU/1", k1.String())
+	a.Equal("0|aU/2", k2.String())
+	a.Equal("1", k1.Suffix())
+
+	a.NotEqual(0, k1.Compare(*k2))
+	for _, k := range []*Key{k1, k2} {
+		a.True(lo.Compare(*k) < 0)
+		a.True(k.Compare(hi) < 0)
+	}
+}
+
+func TestSite_OrderAgainstNeighbours(t *testing.T) {
+	a := assert.New(t)
+
+	plain := mustKey("0|aU")
+	suffixed := mustKey("0|aU/1")
+	longer := mustKey("0|aU0")
+
+	a.True(plain.Compare(suffixed) < 0, "suffix sorts after the bare key")
+	a.True(suffixed.Compare(longer) < 0, "suffix sorts before any longer rank")
+
+	// Generating between suffixed keys works on their ranks.
+	k, ok := suffixed.Between(mustKey("0|aV/2"))
+	a.True(ok)
+	a.True(suffixed.Compare(*k) < 0)
+	a.True(k.Compare(mustKey("0|aV/2")) < 0)
+
+	// Keys that only differ by suffix have no room between them.
+	_, ok = NewSite(3).Between(suffixed, mustKey("0|aU/2"))
+	a.False(ok)
+}
+
+func TestSite_Unique(t *testing.T) {
+	a := assert.New(t)
+
+	rng := rand.New(rand.NewSource(1))
+	sites := []Site{NewSite(0), NewSite(74), NewSite(75), NewSite(1 << 31)}
+
+	for range 1000 {
+		lo := KeyAt(0, rng.Float64())
+		hi := KeyAt(0, rng.Float64())
+
+		seen := map[string]bool{}
+		for _, s := range sites {
+			k, ok := s.Between(lo, hi)
+			if !ok {
+				continue
+			}
+			a.False(seen[k.String()], "two sites produced %s", k)
+			seen[k.String()] = true
+		}
+	}
+}
+
+func TestParseKey_Suffix(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	k, err := ParseKey("1|aaaaaa/zzzzzz")
+	r.NoError(err)
+	a.Equal("aaaaaa", string(k.rank))
+	a.Equal("zzzzzz", k.Suffix())
+	a.Equal(uint8(1), k.bucket)
+
+	for _, s := range []string{"1|a/", "1|a/zzzzzzz", "1|a/~", "1|aaaaaaa/1"} {
+		_, err := ParseKey(s)
+		a.Error(err, s)
+	}
+
+	site, err := ParseSite(k.Suffix())
+	r.NoError(err)
+	a.Equal("zzzzzz", site.String())
+
+	_, err = ParseSite("")
+	a.Error(err)
+}
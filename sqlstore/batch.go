@@ -0,0 +1,196 @@
+package sqlstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Southclaws/lexorank"
+)
+
+// BatchStyle selects the form of statement used to write many keys at once.
+type BatchStyle int
+
+const (
+	// BatchDefault uses BatchValues for Postgres when Config.IDType is set and
+	// BatchCase otherwise.
+	BatchDefault BatchStyle = iota
+
+	// BatchCase works everywhere:
+	//
+	//	UPDATE t SET rank = CASE id WHEN ? THEN ? ... END WHERE id IN (?, ...)
+	BatchCase
+
+	// BatchValues joins against a list of values, Postgres only:
+	//
+	//	UPDATE t SET rank = batch.rank FROM (VALUES (?, ?), ...) AS batch(id, rank) WHERE t.id = batch.id
+	BatchValues
+
+	// BatchUpsert inserts the rows with a conflict clause that only updates the
+	// rank. It's the cheapest form for MySQL and SQLite but every other column
+	// of the table must be nullable or have a default, since the database checks
+	// the insert before it detects the conflict.
+	BatchUpsert
+)
+
+// Default parameter limits for a single statement.
+const (
+	postgresMaxParams = 65535
+	mysqlMaxParams    = 65535
+	sqliteMaxParams   = 32766
+)
+
+// Assignment is a single row being given a new key.
+type Assignment struct {
+	ID  any
+	Key lexorank.Key
+}
+
+// Statement is a parameterised query ready to be executed.
+type Statement struct {
+	Query string
+	Args  []any
+}
+
+// Updates builds the statements that write every assignment, split so that no
+// statement has more than the configured number of parameters.
+func (c Config) Updates(assignments []Assignment) ([]Statement, error) {
+	if len(assignments) == 0 {
+		return nil, nil
+	}
+
+	style := c.batchStyle()
+	if style == BatchValues && c.Dialect != Postgres {
+		return nil, fmt.Errorf("%w: BatchValues is only supported by Postgres", ErrInvalidConfig)
+	}
+
+	perRow := 2
+	if style == BatchCase {
+		perRow = 3
+	}
+
+	size := c.maxParams() / perRow
+	if size < 1 {
+		return nil, fmt.Errorf("%w: MaxParams %d is too small for a single row", ErrInvalidConfig, c.MaxParams)
+	}
+
+	var out []Statement
+	for start := 0; start < len(assignments); start += size {
+		chunk := assignments[start:min(start+size, len(assignments))]
+
+		switch style {
+		case BatchCase:
+			out = append(out, c.caseUpdate(chunk))
+		case BatchValues:
+			out = append(out, c.valuesUpdate(chunk))
+		case BatchUpsert:
+			out = append(out, c.upsert(chunk))
+		}
+	}
+
+	return out, nil
+}
+
+func (c Config) batchStyle() BatchStyle {
+	if c.Batch != BatchDefault {
+		return c.Batch
+	}
+	// Without a cast the VALUES parameters are text, which can't be compared
+	// with a bigint or uuid ID column.
+	if c.Dialect == Postgres && c.IDType != "" {
+		return BatchValues
+	}
+	return BatchCase
+}
+
+func (c Config) maxParams() int {
+	if c.MaxParams > 0 {
+		return c.MaxParams
+	}
+
+	switch c.Dialect {
+	case MySQL:
+		return mysqlMaxParams
+	case SQLite:
+		return sqliteMaxParams
+	default:
+		return postgresMaxParams
+	}
+}
+
+func (c Config) caseUpdate(chunk []Assignment) Statement {
+	var q strings.Builder
+	args := make([]any, 0, len(chunk)*3)
+
+	fmt.Fprintf(&q, "UPDATE %s SET %s = CASE %s", c.Table, c.RankColumn, c.IDColumn)
+	for _, a := range chunk {
+		fmt.Fprintf(&q, " WHEN %s THEN %s",
+			c.Dialect.placeholder(len(args)+1),
+			c.Dialect.placeholder(len(args)+2),
+		)
+		args = append(args, a.ID, a.Key)
+	}
+
+	fmt.Fprintf(&q, " END WHERE %s IN (", c.IDColumn)
+	for i, a := range chunk {
+		if i > 0 {
+			q.WriteString(", ")
+		}
+		q.WriteString(c.Dialect.placeholder(len(args) + 1))
+		args = append(args, a.ID)
+	}
+	q.WriteString(")")
+
+	return Statement{Query: q.String(), Args: args}
+}
+
+func (c Config) valuesUpdate(chunk []Assignment) Statement {
+	var q strings.Builder
+	args := make([]any, 0, len(chunk)*2)
+
+	// Parameters in a VALUES list have no type to infer from, so without a cast
+	// the ID is compared as text.
+	cast := ""
+	if c.IDType != "" {
+		cast = "::" + c.IDType
+	}
+
+	fmt.Fprintf(&q, "UPDATE %s SET %s = batch.rank FROM (VALUES ", c.Table, c.RankColumn)
+	for i, a := range chunk {
+		if i > 0 {
+			q.WriteString(", ")
+		}
+		fmt.Fprintf(&q, "(%s%s, %s)",
+			c.Dialect.placeholder(len(args)+1), cast,
+			c.Dialect.placeholder(len(args)+2),
+		)
+		args = append(args, a.ID, a.Key)
+	}
+	fmt.Fprintf(&q, ") AS batch(id, rank) WHERE %s.%s = batch.id", c.Table, c.IDColumn)
+
+	return Statement{Query: q.String(), Args: args}
+}
+
+func (c Config) upsert(chunk []Assignment) Statement {
+	var q strings.Builder
+	args := make([]any, 0, len(chunk)*2)
+
+	fmt.Fprintf(&q, "INSERT INTO %s (%s, %s) VALUES ", c.Table, c.IDColumn, c.RankColumn)
+	for i, a := range chunk {
+		if i > 0 {
+			q.WriteString(", ")
+		}
+		fmt.Fprintf(&q, "(%s, %s)",
+			c.Dialect.placeholder(len(args)+1),
+			c.Dialect.placeholder(len(args)+2),
+		)
+		args = append(args, a.ID, a.Key)
+	}
+
+	if c.Dialect == MySQL {
+		fmt.Fprintf(&q, " ON DUPLICATE KEY UPDATE %s = VALUES(%s)", c.RankColumn, c.RankColumn)
+	} else {
+		fmt.Fprintf(&q, " ON CONFLICT (%s) DO UPDATE SET %s = excluded.%s", c.IDColumn, c.RankColumn, c.RankColumn)
+	}
+
+	return Statement{Query: q.String(), Args: args}
+}
@@ -0,0 +1,128 @@
+package sqlstore
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/lexorank"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func assignments(n int) []Assignment {
+	out := make([]Assignment, n)
+	for i := range out {
+		out[i] = Assignment{ID: int64(i + 1), Key: lexorank.KeyAt(0, float64(i+1)/float64(n+1))}
+	}
+	return out
+}
+
+func TestConfig_Updates(t *testing.T) {
+	base := Config{Table: "items", IDColumn: "id", RankColumn: "rank"}
+
+	for _, tc := range []struct {
+		name    string
+		dialect Dialect
+		style   BatchStyle
+		idType  string
+	}{
+		{"postgres_values", Postgres, BatchDefault, "uuid"},
+		{"postgres_case", Postgres, BatchCase, ""},
+		{"postgres_upsert", Postgres, BatchUpsert, ""},
+		{"mysql_case", MySQL, BatchDefault, ""},
+		{"mysql_upsert", MySQL, BatchUpsert, ""},
+		{"sqlite_case", SQLite, BatchDefault, ""},
+		{"sqlite_upsert", SQLite, BatchUpsert, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := base
+			cfg.Dialect = tc.dialect
+			cfg.Batch = tc.style
+			cfg.IDType = tc.idType
+
+			// Small enough to split five rows across statements.
+			cfg.MaxParams = 6
+
+			statements, err := cfg.Updates(assignments(5))
+			require.NoError(t, err)
+
+			var got strings.Builder
+			for _, st := range statements {
+				fmt.Fprintln(&got, st.Query)
+				fmt.Fprintln(&got, st.Args...)
+			}
+
+			golden(t, tc.name, got.String())
+		})
+	}
+}
+
+func TestConfig_UpdatesErrors(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := Config{Table: "items", IDColumn: "id", RankColumn: "rank", Dialect: MySQL, Batch: BatchValues}
+	_, err := cfg.Updates(assignments(1))
+	a.ErrorIs(err, ErrInvalidConfig)
+
+	_, err = New(nil, cfg)
+	a.ErrorIs(err, ErrInvalidConfig)
+
+	cfg = Config{Table: "items", IDColumn: "id", RankColumn: "rank", Batch: BatchCase, MaxParams: 2}
+	_, err = cfg.Updates(assignments(1))
+	a.ErrorIs(err, ErrInvalidConfig)
+
+	statements, err := cfg.Updates(nil)
+	a.NoError(err)
+	a.Empty(statements)
+}
+
+func TestConfig_BatchDefault(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := Config{Table: "items", IDColumn: "id", RankColumn: "rank", Dialect: Postgres}
+	a.Equal(BatchCase, cfg.batchStyle(), "without an ID type the VALUES form can't compare IDs")
+
+	cfg.IDType = "bigint"
+	a.Equal(BatchValues, cfg.batchStyle())
+
+	cfg.Dialect = MySQL
+	a.Equal(BatchCase, cfg.batchStyle())
+}
+
+func TestConfig_UpdatesChunking(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := Config{Table: "items", IDColumn: "id", RankColumn: "rank", Dialect: SQLite}
+
+	statements, err := cfg.Updates(assignments(25000))
+	a.NoError(err)
+	a.Len(statements, 3)
+
+	total := 0
+	for _, st := range statements {
+		a.LessOrEqual(len(st.Args), sqliteMaxParams)
+		total += len(st.Args)
+	}
+	a.Equal(25000*3, total)
+}
+
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		require.NoError(t, os.MkdirAll("testdata", 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+}
@@ -0,0 +1,336 @@
+// Package sqlstore persists lexorank ordered lists in a database/sql table.
+//
+// Every operation follows the same pattern: load every row of the scope,
+// locking them for the duration of a transaction, run one or more list
+// operations and then write back only the rows whose rank changed. Large scopes
+// that can't afford to be loaded whole should use lexorank.NeighbourFetcher.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Southclaws/lexorank"
+)
+
+var (
+	ErrInvalidConfig = fmt.Errorf("invalid config")
+	ErrNotFound      = fmt.Errorf("row not found")
+)
+
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	MySQL
+	SQLite
+)
+
+func (d Dialect) placeholder(n int) string {
+	if d == Postgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// lock returns the locking clause for reading siblings. SQLite has no row level
+// locks, its transactions already serialise writers.
+func (d Dialect) lock() string {
+	if d == SQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// Config describes the table that holds the ordered rows.
+type Config struct {
+	Table      string
+	IDColumn   string
+	RankColumn string
+
+	// ScopeColumn is optional, when set, each distinct value of the column is
+	// its own ordered list, such as the parent of a tree node or the column of
+	// a kanban board.
+	ScopeColumn string
+
+	Dialect Dialect
+
+	// Batch selects how changed keys are written, see BatchStyle.
+	Batch BatchStyle
+
+	// IDType is the Postgres type of the ID column, such as "uuid" or "bigint".
+	// It's needed by BatchValues unless the column is text, so BatchDefault
+	// only picks BatchValues when it's set.
+	IDType string
+
+	// MaxParams limits the parameters in a single write statement, larger
+	// changes are split across several statements. Zero uses the limit of the
+	// dialect.
+	MaxParams int
+
+	// Observer is told about the rebalances done by Insert, Move and
+	// Normalise, with the scope as the list ID. It defaults to the Observer
+	// installed with lexorank.SetObserver.
+	Observer lexorank.Observer
+}
+
+var (
+	identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+	typeName   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_ ]*$`)
+)
+
+func (c Config) validate() error {
+	for name, v := range map[string]string{
+		"Table":      c.Table,
+		"IDColumn":   c.IDColumn,
+		"RankColumn": c.RankColumn,
+	} {
+		if !identifier.MatchString(v) {
+			return fmt.Errorf("%w: %s %q is not a valid identifier", ErrInvalidConfig, name, v)
+		}
+	}
+
+	if c.ScopeColumn != "" && !identifier.MatchString(c.ScopeColumn) {
+		return fmt.Errorf("%w: ScopeColumn %q is not a valid identifier", ErrInvalidConfig, c.ScopeColumn)
+	}
+
+	if c.IDType != "" && !typeName.MatchString(c.IDType) {
+		return fmt.Errorf("%w: IDType %q is not a valid type name", ErrInvalidConfig, c.IDType)
+	}
+
+	if c.Batch == BatchValues && c.Dialect != Postgres {
+		return fmt.Errorf("%w: BatchValues is only supported by Postgres", ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// Row is a single row loaded from the table, it implements lexorank.Reorderable
+// so the rows of a scope can be used as a lexorank.ReorderableList.
+type Row struct {
+	ID  any
+	Key lexorank.Key
+}
+
+func (r *Row) GetKey() lexorank.Key  { return r.Key }
+func (r *Row) SetKey(k lexorank.Key) { r.Key = k }
+
+type Store struct {
+	db  *sql.DB
+	cfg Config
+}
+
+func New(db *sql.DB, cfg Config) (*Store, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &Store{db: db, cfg: cfg}, nil
+}
+
+// Update loads and locks every row in a scope, in key order, and passes them to
+// fn as a lexorank.ReorderableList. Any row whose key was changed by fn is written back
+// before the transaction commits. The scope is ignored if the store has no
+// ScopeColumn.
+//
+// The transaction is passed to fn so it can make other writes atomically, such
+// as inserting a new row with a key generated from the list.
+func (s *Store) Update(ctx context.Context, scope any, fn func(tx *sql.Tx, list lexorank.ReorderableList) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := s.load(ctx, tx, scope)
+	if err != nil {
+		return err
+	}
+
+	before := make(map[*Row]lexorank.Key, len(rows))
+	list := make(lexorank.ReorderableList, len(rows))
+	for i, r := range rows {
+		before[r] = r.Key
+		list[i] = r
+	}
+
+	if err := fn(tx, list); err != nil {
+		return err
+	}
+
+	var dirty []*Row
+	for _, r := range rows {
+		if r.Key.Compare(before[r]) != 0 {
+			dirty = append(dirty, r)
+		}
+	}
+
+	if err := s.write(ctx, tx, dirty); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Insert generates a key for a new row at the given position within a scope,
+// rebalancing siblings if necessary. The create function is called with the
+// key inside the same transaction and is expected to insert the new row.
+func (s *Store) Insert(ctx context.Context, scope any, position uint, create func(tx *sql.Tx, key lexorank.Key) error) (lexorank.Key, error) {
+	var key lexorank.Key
+
+	err := s.Update(ctx, scope, func(tx *sql.Tx, list lexorank.ReorderableList) error {
+		// New rows start in bucket 0, like lexorank.Middle.
+		k, err := s.observed(scope, list).InsertIn(0, position)
+		if err != nil {
+			return err
+		}
+		key = *k
+
+		return create(tx, key)
+	})
+
+	return key, err
+}
+
+// Move places an existing row at the given position within its scope, which is
+// an index into the scope as it looks once the row has been taken out.
+func (s *Store) Move(ctx context.Context, scope any, id any, position uint) (lexorank.Key, error) {
+	want, err := normaliseID(id)
+	if err != nil {
+		return lexorank.Key{}, err
+	}
+
+	var key lexorank.Key
+
+	err = s.Update(ctx, scope, func(tx *sql.Tx, list lexorank.ReorderableList) error {
+		index := -1
+		for i, r := range list {
+			if r.(*Row).ID == want {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return ErrNotFound
+		}
+
+		moved := list[index]
+		rest := append(list[:index:index], list[index+1:]...)
+
+		k, err := s.observed(scope, rest).InsertIn(moved.GetKey().Bucket(), position)
+		if err != nil {
+			return err
+		}
+
+		key = *k
+		moved.SetKey(key)
+
+		return nil
+	})
+
+	return key, err
+}
+
+func (s *Store) observed(scope any, list lexorank.ReorderableList) lexorank.ObservedList {
+	return lexorank.ObservedList{ReorderableList: list, ID: fmt.Sprint(scope), Observer: s.cfg.Observer}
+}
+
+// Normalise evenly distributes every row in a scope across the key space and
+// returns the number of rows that were written.
+func (s *Store) Normalise(ctx context.Context, scope any) (int, error) {
+	n := 0
+
+	err := s.Update(ctx, scope, func(tx *sql.Tx, list lexorank.ReorderableList) error {
+		before := make([]lexorank.Key, len(list))
+		for i, r := range list {
+			before[i] = r.GetKey()
+		}
+
+		s.observed(scope, list).Normalise()
+
+		for i, r := range list {
+			if r.GetKey().Compare(before[i]) != 0 {
+				n++
+			}
+		}
+		return nil
+	})
+
+	return n, err
+}
+
+func (s *Store) load(ctx context.Context, tx *sql.Tx, scope any) ([]*Row, error) {
+	var q strings.Builder
+	var args []any
+
+	fmt.Fprintf(&q, "SELECT %s, %s FROM %s", s.cfg.IDColumn, s.cfg.RankColumn, s.cfg.Table)
+	if s.cfg.ScopeColumn != "" {
+		fmt.Fprintf(&q, " WHERE %s = %s", s.cfg.ScopeColumn, s.cfg.Dialect.placeholder(1))
+		args = append(args, scope)
+	}
+	fmt.Fprintf(&q, " ORDER BY %s%s", s.cfg.RankColumn, s.cfg.Dialect.lock())
+
+	rows, err := tx.QueryContext(ctx, q.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Ranks are sorted again by key, as ORDER BY follows the collation of the
+	// column, which only matches key order for binary collations such as C.
+	var out []*Row
+	for rows.Next() {
+		r := &Row{}
+		if err := rows.Scan(&r.ID, &r.Key); err != nil {
+			return nil, err
+		}
+		if r.ID, err = normaliseID(r.ID); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(out, func(a, b *Row) int { return a.Key.Compare(b.Key) })
+
+	return out, nil
+}
+
+func (s *Store) write(ctx context.Context, tx *sql.Tx, dirty []*Row) error {
+	assignments := make([]Assignment, len(dirty))
+	for i, r := range dirty {
+		assignments[i] = Assignment{ID: r.ID, Key: r.Key}
+	}
+
+	statements, err := s.cfg.Updates(assignments)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range statements {
+		if _, err := tx.ExecContext(ctx, st.Query, st.Args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// normaliseID converts an ID to the form it's returned in by a driver so IDs
+// supplied by the caller can be compared with scanned ones.
+func normaliseID(id any) (any, error) {
+	v, err := driver.DefaultParameterConverter.ConvertValue(id)
+	if err != nil {
+		return nil, err
+	}
+	if b, ok := v.([]byte); ok {
+		return string(b), nil
+	}
+	return v, nil
+}
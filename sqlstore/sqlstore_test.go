@@ -0,0 +1,351 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Southclaws/lexorank"
+)
+
+// fakeDB is an in-memory table of (id, scope, rank) rows that understands just
+// enough SQL to serve the statements the store issues, and records them.
+type fakeDB struct {
+	mu      sync.Mutex
+	rows    map[int64]*fakeRow
+	queries []string
+	commits int
+
+	// collate orders the rows returned by SELECT, like a database collation.
+	// It defaults to comparing bytes.
+	collate func(a, b string) bool
+}
+
+type fakeRow struct {
+	scope string
+	rank  string
+}
+
+var (
+	fakes   = map[string]*fakeDB{}
+	fakesMu sync.Mutex
+)
+
+func init() {
+	sql.Register("fake", fakeDriver{})
+}
+
+func newFake(t *testing.T, rows map[int64][2]string) (*sql.DB, *fakeDB) {
+	f := &fakeDB{rows: map[int64]*fakeRow{}}
+	for id, r := range rows {
+		f.rows[id] = &fakeRow{scope: r[0], rank: r[1]}
+	}
+
+	fakesMu.Lock()
+	fakes[t.Name()] = f
+	fakesMu.Unlock()
+
+	db, err := sql.Open("fake", t.Name())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return db, f
+}
+
+// order returns the ids of a scope in rank order.
+func (f *fakeDB) order(scope string) []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var ids []int64
+	for id, r := range f.rows {
+		if r.scope == scope {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return f.rows[ids[i]].rank < f.rows[ids[j]].rank })
+	return ids
+}
+
+func (f *fakeDB) updates() int {
+	n := 0
+	for _, q := range f.queries {
+		if !strings.HasPrefix(q, "SELECT") && !strings.HasPrefix(q, "INSERT INTO items (id, list_id, rank)") {
+			n++
+		}
+	}
+	return n
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakesMu.Lock()
+	defer fakesMu.Unlock()
+	return &fakeConn{db: fakes[name]}, nil
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{c.db}, nil }
+
+type fakeTx struct{ db *fakeDB }
+
+func (t fakeTx) Commit() error {
+	t.db.mu.Lock()
+	t.db.commits++
+	t.db.mu.Unlock()
+	return nil
+}
+func (t fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	s.db.queries = append(s.db.queries, s.query)
+
+	// Every batch form starts with (id, rank) pairs, the CASE form follows them
+	// with the ids again.
+	var pairs []driver.Value
+	switch {
+	case strings.Contains(s.query, "CASE"):
+		pairs = args[:len(args)/3*2]
+	case strings.HasPrefix(s.query, "UPDATE"), strings.Contains(s.query, " ON "):
+		pairs = args
+	}
+
+	if pairs != nil {
+		n := 0
+		for i := 0; i < len(pairs); i += 2 {
+			if r, ok := s.db.rows[pairs[i].(int64)]; ok {
+				r.rank = pairs[i+1].(string)
+				n++
+			}
+		}
+		return driver.RowsAffected(n), nil
+	}
+
+	if strings.HasPrefix(s.query, "INSERT") {
+		s.db.rows[args[0].(int64)] = &fakeRow{scope: args[1].(string), rank: args[2].(string)}
+		return driver.RowsAffected(1), nil
+	}
+
+	return nil, fmt.Errorf("fake: unsupported statement %q", s.query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	s.db.queries = append(s.db.queries, s.query)
+	s.db.mu.Unlock()
+
+	scope := ""
+	if len(args) > 0 {
+		scope = args[0].(string)
+	}
+
+	ids := s.db.order(scope)
+	if s.db.collate != nil {
+		sort.SliceStable(ids, func(i, j int) bool { return s.db.collate(s.db.rows[ids[i]].rank, s.db.rows[ids[j]].rank) })
+	}
+
+	rows := &fakeRows{}
+	for _, id := range ids {
+		rows.values = append(rows.values, []driver.Value{id, []byte(s.db.rows[id].rank)})
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id", "rank"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+var testConfig = Config{
+	Table:       "items",
+	IDColumn:    "id",
+	RankColumn:  "rank",
+	ScopeColumn: "list_id",
+	Dialect:     Postgres,
+	IDType:      "bigint",
+}
+
+func TestNew_Validation(t *testing.T) {
+	a := assert.New(t)
+
+	for _, cfg := range []Config{
+		{Table: "items; DROP TABLE items", IDColumn: "id", RankColumn: "rank"},
+		{Table: "items", IDColumn: "", RankColumn: "rank"},
+		{Table: "items", IDColumn: "id", RankColumn: "rank", ScopeColumn: "a b"},
+	} {
+		_, err := New(nil, cfg)
+		a.ErrorIs(err, ErrInvalidConfig)
+	}
+
+	_, err := New(nil, Config{Table: "public.items", IDColumn: "id", RankColumn: "rank"})
+	a.NoError(err)
+}
+
+func TestStore_Move(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	db, f := newFake(t, map[int64][2]string{
+		1: {"a", "0|a"},
+		2: {"a", "0|b"},
+		3: {"a", "0|c"},
+		4: {"b", "0|a"},
+	})
+
+	s, err := New(db, testConfig)
+	r.NoError(err)
+
+	k, err := s.Move(context.Background(), "a", 3, 0)
+	r.NoError(err)
+	a.True(k.Compare(lexorank.BottomOf(0)) > 0)
+
+	a.Equal([]int64{3, 1, 2}, f.order("a"))
+	a.Equal([]int64{4}, f.order("b"))
+	a.Equal(1, f.updates(), "only the moved row is written")
+	a.Equal(1, f.commits)
+
+	a.Equal("SELECT id, rank FROM items WHERE list_id = $1 ORDER BY rank FOR UPDATE", f.queries[0])
+	a.Equal("UPDATE items SET rank = batch.rank FROM (VALUES ($1::bigint, $2)) AS batch(id, rank) WHERE items.id = batch.id", f.queries[1])
+
+	_, err = s.Move(context.Background(), "a", 99, 0)
+	a.ErrorIs(err, ErrNotFound)
+	a.Equal(1, f.commits)
+}
+
+func TestStore_MoveCollation(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	db, f := newFake(t, map[int64][2]string{
+		1: {"a", "0|B"},
+		2: {"a", "0|a"},
+		3: {"a", "0|c"},
+	})
+	f.collate = func(a, b string) bool { return strings.ToLower(a) < strings.ToLower(b) }
+
+	s, err := New(db, testConfig)
+	r.NoError(err)
+
+	_, err = s.Move(context.Background(), "a", 3, 0)
+	r.NoError(err)
+	a.Equal([]int64{3, 1, 2}, f.order("a"), "rows are ordered by key rather than by the collation")
+}
+
+func TestStore_InsertRebalances(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	db, f := newFake(t, map[int64][2]string{
+		1: {"a", "0|a"},
+		2: {"a", "0|a00000"},
+		3: {"a", "0|a00001"},
+	})
+
+	cfg := testConfig
+	rebalanced := &rebalances{}
+	cfg.Observer = rebalanced
+
+	s, err := New(db, cfg)
+	r.NoError(err)
+
+	_, err = s.Insert(context.Background(), "a", 2, func(tx *sql.Tx, key lexorank.Key) error {
+		_, err := tx.Exec("INSERT INTO items (id, list_id, rank) VALUES ($1, $2, $3)", 5, "a", key)
+		return err
+	})
+	r.NoError(err)
+
+	a.Equal([]int64{1, 2, 5, 3}, f.order("a"))
+	a.NotZero(f.updates(), "neighbours were rebalanced to make room")
+	a.Equal([]string{"a"}, rebalanced.lists, "events are tagged with the scope")
+}
+
+func TestStore_InsertEmptyScope(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	db, f := newFake(t, map[int64][2]string{})
+
+	s, err := New(db, testConfig)
+	r.NoError(err)
+
+	for i, id := range []int64{1, 2} {
+		_, err = s.Insert(context.Background(), "a", uint(i), func(tx *sql.Tx, key lexorank.Key) error {
+			_, err := tx.Exec("INSERT INTO items (id, list_id, rank) VALUES ($1, $2, $3)", id, "a", key)
+			return err
+		})
+		r.NoError(err)
+	}
+
+	a.Equal([]int64{1, 2}, f.order("a"))
+	a.Equal(lexorank.Middle.String(), f.rows[1].rank)
+	a.Zero(f.updates(), "the first row leaves room after it")
+}
+
+// rebalances records the lists that were rebalanced or normalised.
+type rebalances struct {
+	lists []string
+}
+
+func (r *rebalances) BetweenFailed(list string, lo, hi lexorank.Key)     {}
+func (r *rebalances) Rebalanced(list string, touched, direction int)     { r.lists = append(r.lists, list) }
+func (r *rebalances) Normalised(list string, n int, forced bool)         { r.lists = append(r.lists, list) }
+func (r *rebalances) LongKey(list string, k lexorank.Key, threshold int) {}
+
+func TestStore_SQLiteAndNoScope(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	db, f := newFake(t, map[int64][2]string{
+		1: {"", "0|a"},
+		2: {"", "0|a0"},
+		3: {"", "0|a00001"},
+	})
+
+	s, err := New(db, Config{Table: "items", IDColumn: "id", RankColumn: "rank", Dialect: SQLite})
+	r.NoError(err)
+
+	n, err := s.Normalise(context.Background(), nil)
+	r.NoError(err)
+	a.Equal(3, n)
+	a.Equal(1, f.updates(), "every row is written in a single statement")
+
+	a.Equal("SELECT id, rank FROM items ORDER BY rank", f.queries[0])
+	a.Equal("UPDATE items SET rank = CASE id WHEN ? THEN ? WHEN ? THEN ? WHEN ? THEN ? END WHERE id IN (?, ?, ?)", f.queries[1])
+	a.Equal([]int64{1, 2, 3}, f.order(""))
+}
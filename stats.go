@@ -0,0 +1,54 @@
+package lexorank
+
+// CrowdedGap is the distance, in units of the last rank character, below which
+// two adjacent keys are considered crowded. Repeatedly inserting between them
+// leaves room for roughly a dozen more keys before a rebalance is needed.
+const CrowdedGap = 75 * 75
+
+// Stats describes how the keys of a list are distributed.
+type Stats struct {
+	Len int
+
+	// MinGap is the smallest distance between two adjacent keys, in units of
+	// the last rank character. It's zero for lists with fewer than two items.
+	MinGap int64
+
+	// MeanGap is the average distance between adjacent keys.
+	MeanGap float64
+
+	// MaxLength is the length of the longest rank in the list.
+	MaxLength int
+
+	// Crowded is the number of adjacent pairs closer than CrowdedGap.
+	Crowded int
+}
+
+// Stats reports how crowded the keys of a sorted list are.
+func (l ReorderableList) Stats() Stats {
+	s := Stats{Len: len(l)}
+
+	var total int64
+	for i, item := range l {
+		k := item.GetKey()
+		s.MaxLength = max(s.MaxLength, len(k.rank))
+
+		if i == 0 {
+			continue
+		}
+
+		gap := k.position() - l[i-1].GetKey().position()
+		if i == 1 || gap < s.MinGap {
+			s.MinGap = gap
+		}
+		if gap < CrowdedGap {
+			s.Crowded++
+		}
+		total += gap
+	}
+
+	if len(l) > 1 {
+		s.MeanGap = float64(total) / float64(len(l)-1)
+	}
+
+	return s
+}
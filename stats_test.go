@@ -0,0 +1,31 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorderableList_Stats(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(Stats{}, ReorderableList{}.Stats())
+	a.Equal(Stats{Len: 1, MaxLength: 1}, ReorderableList{item(1, "0|a")}.Stats())
+
+	l := ReorderableList{
+		item(1, "0|a"),
+		item(2, "0|b"),
+		item(3, "0|b0001"),
+		item(4, "0|c"),
+	}
+
+	s := l.Stats()
+	a.Equal(4, s.Len)
+	a.Equal(int64(75), s.MinGap)
+	a.Equal(5, s.MaxLength)
+	a.Equal(1, s.Crowded)
+	a.InDelta(float64(2*75*75*75*75*75)/3, s.MeanGap, 0.001)
+
+	l.Normalise()
+	a.Zero(l.Stats().Crowded)
+}
@@ -0,0 +1,73 @@
+package lexorank
+
+import (
+	"fmt"
+	"iter"
+)
+
+var ErrCountMismatch = fmt.Errorf("number of items does not match the expected count")
+
+// EvenlySpaced yields the index and key of every item of a normalised list of n
+// items, without holding the list in memory. The keys are exactly those that
+// Normalise would give a list of n items in the same bucket.
+func EvenlySpaced(bucket uint8, n int) iter.Seq2[int, Key] {
+	return func(yield func(int, Key) bool) {
+		for i := range n {
+			if !yield(i, normaliseKey(bucket, i, n)) {
+				return
+			}
+		}
+	}
+}
+
+// StreamNormalise normalises a list that's too large to hold in memory, such as
+// one read from a database cursor. Items must be yielded in key order and n must
+// be the number of items that will be yielded, since the spacing of the keys
+// depends on it.
+//
+// Each item is given the same key Normalise would give it and the items whose
+// key changed are yielded in chunks of up to size changes, ready to be written
+// back in bulk. If the number of items does not match n, ErrCountMismatch is
+// yielded once the mismatch is found.
+func StreamNormalise(items iter.Seq[Reorderable], n int, size int) iter.Seq2[Changes, error] {
+	return func(yield func(Changes, error) bool) {
+		size := max(1, size)
+		chunk := make(Changes, 0, size)
+
+		i := 0
+		for item := range items {
+			if i >= n {
+				yield(nil, fmt.Errorf("%w: more than %d items", ErrCountMismatch, n))
+				return
+			}
+
+			old := item.GetKey()
+			k := normaliseKey(old.bucket, i, n)
+			i++
+
+			if old.Compare(k) == 0 {
+				continue
+			}
+
+			item.SetKey(k)
+			chunk = append(chunk, Change{Item: item, Old: old, New: k})
+
+			if len(chunk) == size {
+				if !yield(chunk, nil) {
+					return
+				}
+				chunk = make(Changes, 0, size)
+			}
+		}
+
+		if len(chunk) > 0 {
+			if !yield(chunk, nil) {
+				return
+			}
+		}
+
+		if i < n {
+			yield(nil, fmt.Errorf("%w: expected %d items, got %d", ErrCountMismatch, n, i))
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package lexorank
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvenlySpaced(t *testing.T) {
+	a := assert.New(t)
+
+	l := largeList(1000)
+	l.Normalise()
+
+	var keys Keys
+	for i, k := range EvenlySpaced(1, 1000) {
+		a.Equal(len(keys), i)
+		keys = append(keys, k)
+	}
+	a.Equal(l.keys(), keys)
+
+	for i := range EvenlySpaced(0, 1000) {
+		if i == 9 {
+			break
+		}
+	}
+}
+
+func TestStreamNormalise(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	want := largeList(10_000)
+	want.Normalise()
+
+	l := largeList(10_000)
+
+	// Already in place, so it's not part of the changes.
+	l[42].SetKey(want[42].GetKey())
+
+	var changes Changes
+	chunks := 0
+	for chunk, err := range StreamNormalise(slices.Values(l), len(l), 1000) {
+		r.NoError(err)
+		a.LessOrEqual(len(chunk), 1000)
+		changes = append(changes, chunk...)
+		chunks++
+	}
+
+	a.Equal(10, chunks)
+	a.Len(changes, 9999)
+	a.Equal(want.keys(), l.keys())
+
+	for _, c := range changes {
+		a.Equal(c.New, c.Item.GetKey())
+	}
+}
+
+func TestStreamNormalise_CountMismatch(t *testing.T) {
+	a := assert.New(t)
+
+	var errs []error
+	n := 0
+	for chunk, err := range StreamNormalise(slices.Values(largeList(10)), 11, 4) {
+		n += len(chunk)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	a.NotZero(n, "changes before the mismatch are still yielded")
+	a.Len(errs, 1)
+	a.ErrorIs(errs[0], ErrCountMismatch)
+
+	errs = nil
+	for _, err := range StreamNormalise(slices.Values(largeList(10)), 9, 100) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	a.Len(errs, 1)
+	a.ErrorIs(errs[0], ErrCountMismatch)
+}
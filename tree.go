@@ -0,0 +1,216 @@
+package lexorank
+
+import (
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrNodeExists = fmt.Errorf("node already exists")
+	ErrCycle      = fmt.Errorf("node cannot be moved into its own subtree")
+)
+
+// Tree orders hierarchical content where every node has a parent and a key that
+// is only meaningful among its siblings. Each set of siblings is its own key
+// space and all key generation goes through ReorderableList, so the same
+// rebalancing rules apply to every level of the tree.
+//
+// The tree has a single root node which is never moved and never reported by
+// Walk, it exists so top-level nodes have something to be children of. Use an
+// identifier that will never be used by a real node, such as the zero value.
+type Tree[T comparable] struct {
+	root  *TreeNode[T]
+	nodes map[T]*TreeNode[T]
+}
+
+// TreeNode is a single node in a Tree. It implements Reorderable so that its
+// siblings can be treated as a ReorderableList.
+type TreeNode[T comparable] struct {
+	ID T
+
+	key      Key
+	parent   *TreeNode[T]
+	children []*TreeNode[T]
+}
+
+func (n *TreeNode[T]) GetKey() Key  { return n.key }
+func (n *TreeNode[T]) SetKey(k Key) { n.key = k }
+
+// Parent returns the node's parent, or nil for the root node.
+func (n *TreeNode[T]) Parent() *TreeNode[T] { return n.parent }
+
+// Children returns the node's children ordered by key.
+func (n *TreeNode[T]) Children() []*TreeNode[T] { return slices.Clone(n.children) }
+
+// Depth returns the number of ancestors between the node and the root, so top
+// level nodes have a depth of zero.
+func (n *TreeNode[T]) Depth() int {
+	depth := -1
+	for p := n.parent; p != nil; p = p.parent {
+		depth++
+	}
+	return depth
+}
+
+// IsAncestorOf reports whether n is a strict ancestor of o.
+func (n *TreeNode[T]) IsAncestorOf(o *TreeNode[T]) bool {
+	for p := o.parent; p != nil; p = p.parent {
+		if p == n {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *TreeNode[T]) siblings() ReorderableList {
+	l := make(ReorderableList, len(n.children))
+	for i, c := range n.children {
+		l[i] = c
+	}
+	return l
+}
+
+func NewTree[T comparable](root T) *Tree[T] {
+	r := &TreeNode[T]{ID: root}
+	return &Tree[T]{
+		root:  r,
+		nodes: map[T]*TreeNode[T]{root: r},
+	}
+}
+
+// Root returns the root node of the tree.
+func (t *Tree[T]) Root() *TreeNode[T] { return t.root }
+
+// Node looks up a node by its identifier.
+func (t *Tree[T]) Node(id T) (*TreeNode[T], bool) {
+	n, ok := t.nodes[id]
+	return n, ok
+}
+
+// Add places an existing node with a known key into the tree, this is how you
+// load a tree from storage. Parents must be added before their children.
+func (t *Tree[T]) Add(id, parent T, key Key) error {
+	if _, ok := t.nodes[id]; ok {
+		return ErrNodeExists
+	}
+	p, ok := t.nodes[parent]
+	if !ok {
+		return ErrItemNotFound
+	}
+
+	n := &TreeNode[T]{ID: id, key: key, parent: p}
+
+	i := slices.IndexFunc(p.children, func(c *TreeNode[T]) bool { return c.key.Compare(key) > 0 })
+	if i == -1 {
+		i = len(p.children)
+	}
+	p.children = slices.Insert(p.children, i, n)
+	t.nodes[id] = n
+
+	return nil
+}
+
+// Insert creates a new node under parent at the given sibling position and
+// generates a key for it. The returned changes include the new node itself
+// along with any siblings that were rebalanced to make room.
+func (t *Tree[T]) Insert(id, parent T, position uint) (Changes, error) {
+	if _, ok := t.nodes[id]; ok {
+		return nil, ErrNodeExists
+	}
+	p, ok := t.nodes[parent]
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+
+	n := &TreeNode[T]{ID: id}
+
+	changes, err := t.place(n, p, position)
+	if err != nil {
+		return nil, err
+	}
+	t.nodes[id] = n
+
+	return changes, nil
+}
+
+// MoveNode moves a node, along with its entire subtree, to the given sibling
+// position under a new parent. The parent may be the node's current parent in
+// which case this is a plain re-order. Moving a node underneath itself or any
+// of its descendants fails with ErrCycle.
+//
+// The moved node is always included in the returned changes, even if its key
+// happens to stay the same, because its parent has changed. Descendants keep
+// their keys since keys are scoped to siblings.
+func (t *Tree[T]) MoveNode(id, parent T, position uint) (Changes, error) {
+	n, ok := t.nodes[id]
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+	p, ok := t.nodes[parent]
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+
+	if n == p || n.IsAncestorOf(p) {
+		return nil, ErrCycle
+	}
+
+	size := len(p.children)
+	if n.parent == p {
+		size--
+	}
+	if position > uint(size) {
+		return nil, ErrOutOfBounds
+	}
+
+	old := n.parent
+	index := slices.Index(old.children, n)
+	old.children = slices.Delete(old.children, index, index+1)
+
+	changes, err := t.place(n, p, position)
+	if err != nil {
+		old.children = slices.Insert(old.children, index, n)
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// place generates a key for n at position among the children of p and links
+// it into the tree.
+func (t *Tree[T]) place(n, p *TreeNode[T], position uint) (Changes, error) {
+	siblings := p.siblings()
+	before := siblings.keys()
+
+	k, err := siblings.InsertIn(n.key.bucket, position)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := siblings.changes(before)
+	changes = append(changes, Change{Item: n, Old: n.key, New: *k})
+
+	n.key = *k
+	n.parent = p
+	p.children = slices.Insert(p.children, int(position), n)
+
+	return changes, nil
+}
+
+// Walk visits every node depth-first, with siblings visited in key order. The
+// root node is not visited. Returning false from fn stops the walk.
+func (t *Tree[T]) Walk(fn func(n *TreeNode[T], depth int) bool) {
+	walk(t.root.children, 0, fn)
+}
+
+func walk[T comparable](nodes []*TreeNode[T], depth int, fn func(n *TreeNode[T], depth int) bool) bool {
+	for _, n := range nodes {
+		if !fn(n, depth) {
+			return false
+		}
+		if !walk(n.children, depth+1, fn) {
+			return false
+		}
+	}
+	return true
+}
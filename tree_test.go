@@ -0,0 +1,170 @@
+package lexorank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustKey(s string) Key {
+	k, err := ParseKey(s)
+	if err != nil {
+		panic(err)
+	}
+	return *k
+}
+
+func flatten(t *Tree[string]) []string {
+	var out []string
+	t.Walk(func(n *TreeNode[string], depth int) bool {
+		out = append(out, n.ID)
+		return true
+	})
+	return out
+}
+
+// testTree builds a tree with two top-level nodes, a and b, where a has the
+// children a1 and a2 and b has the single child b1.
+func testTree(t *testing.T) *Tree[string] {
+	r := require.New(t)
+
+	tree := NewTree("")
+	r.NoError(tree.Add("b", "", mustKey("0|b")))
+	r.NoError(tree.Add("a", "", mustKey("0|a")))
+	r.NoError(tree.Add("a2", "a", mustKey("0|U")))
+	r.NoError(tree.Add("a1", "a", mustKey("0|A")))
+	r.NoError(tree.Add("b1", "b", mustKey("0|U")))
+
+	return tree
+}
+
+func TestTree_Walk(t *testing.T) {
+	a := assert.New(t)
+
+	tree := testTree(t)
+
+	a.Equal([]string{"a", "a1", "a2", "b", "b1"}, flatten(tree))
+
+	var depths []int
+	tree.Walk(func(n *TreeNode[string], depth int) bool {
+		depths = append(depths, depth)
+		a.Equal(n.Depth(), depth)
+		return n.ID != "a2"
+	})
+	a.Equal([]int{0, 1, 1}, depths, "walk stops when fn returns false")
+}
+
+func TestTree_Add_Errors(t *testing.T) {
+	a := assert.New(t)
+
+	tree := testTree(t)
+
+	a.Equal(ErrNodeExists, tree.Add("a", "", mustKey("0|c")))
+	a.Equal(ErrItemNotFound, tree.Add("c", "nope", mustKey("0|c")))
+}
+
+func TestTree_Insert(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	tree := testTree(t)
+
+	changes, err := tree.Insert("a0", "a", 0)
+	r.NoError(err)
+	r.Len(changes, 1)
+
+	n, ok := tree.Node("a0")
+	r.True(ok)
+	a.Equal(n, changes[0].Item)
+	a.True(n.GetKey().Compare(mustKey("0|A")) < 0)
+
+	a.Equal([]string{"a", "a0", "a1", "a2", "b", "b1"}, flatten(tree))
+}
+
+func TestTree_Insert_FirstChild(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	tree := NewTree("")
+	for i, id := range []string{"a", "b", "c"} {
+		changes, err := tree.Insert(id, "", uint(i))
+		r.NoError(err)
+		a.Len(changes, 1, "only %s is given a key", id)
+	}
+
+	n, _ := tree.Node("a")
+	a.Equal(Middle, n.GetKey())
+	a.Equal([]string{"a", "b", "c"}, flatten(tree))
+}
+
+func TestTree_MoveNode_Subtree(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	tree := testTree(t)
+
+	changes, err := tree.MoveNode("a", "b", 1)
+	r.NoError(err)
+	r.Len(changes, 1)
+
+	a.Equal([]string{"b", "b1", "a", "a1", "a2"}, flatten(tree))
+
+	n, _ := tree.Node("a1")
+	a.Equal(2, n.Depth())
+	a.Equal("0|A", n.GetKey().String(), "descendants keep their keys")
+
+	b, _ := tree.Node("b")
+	a.True(b.IsAncestorOf(n))
+}
+
+func TestTree_MoveNode_Reorder(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	tree := testTree(t)
+
+	_, err := tree.MoveNode("a1", "a", 1)
+	r.NoError(err)
+	a.Equal([]string{"a", "a2", "a1", "b", "b1"}, flatten(tree))
+}
+
+func TestTree_MoveNode_Rebalance(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	tree := NewTree(0)
+	r.NoError(tree.Add(1, 0, mustKey("0|aaaaaa")))
+	r.NoError(tree.Add(2, 0, mustKey("0|aaaaab")))
+	r.NoError(tree.Add(3, 1, mustKey("0|U")))
+
+	changes, err := tree.MoveNode(3, 0, 1)
+	r.NoError(err)
+	a.Greater(len(changes), 1)
+
+	root := tree.Root()
+	a.True(root.siblings().IsSorted())
+}
+
+func TestTree_MoveNode_Errors(t *testing.T) {
+	a := assert.New(t)
+
+	tree := testTree(t)
+
+	_, err := tree.MoveNode("a", "a1", 0)
+	a.Equal(ErrCycle, err)
+
+	_, err = tree.MoveNode("a", "a", 0)
+	a.Equal(ErrCycle, err)
+
+	_, err = tree.MoveNode("", "a", 0)
+	a.Equal(ErrCycle, err, "the root cannot be moved")
+
+	_, err = tree.MoveNode("nope", "a", 0)
+	a.Equal(ErrItemNotFound, err)
+
+	_, err = tree.MoveNode("a1", "b", 2)
+	a.Equal(ErrOutOfBounds, err)
+
+	a.Equal([]string{"a", "a1", "a2", "b", "b1"}, flatten(tree), "failed moves leave the tree untouched")
+}
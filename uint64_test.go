@@ -0,0 +1,81 @@
+package lexorank
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey_Uint64(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	a.Equal(uint64(0), Bottom.Uint64())
+	a.Equal(uint64(maxValue-1), Top.Uint64())
+	a.Equal(uint64(1)<<38, BottomOf(1).Uint64())
+	a.Equal(mustKey("0|U").Uint64(), mustKey("0|U00000").Uint64())
+
+	v, err := mustKey("0|U").CheckedUint64()
+	a.NoError(err)
+	a.Equal(mustKey("0|U").Uint64(), v)
+	_, err = mustKey("0|U/1").CheckedUint64()
+	a.ErrorIs(err, ErrSuffix)
+
+	for _, s := range []string{"0|0", "0|U", "1|a0001", "2|zzzzzz"} {
+		k := mustKey(s)
+		back, err := KeyFromUint64(k.bucket, k.Uint64())
+		r.NoError(err)
+		a.Equal(k, back)
+	}
+
+	_, err = KeyFromUint64(0, BottomOf(1).Uint64())
+	a.ErrorIs(err, ErrBucketMismatch)
+	_, err = KeyFromUint64(0, uint64(maxValue))
+	a.ErrorIs(err, ErrOverflow)
+	_, err = KeyFromUint64(3, 3<<38)
+	a.ErrorIs(err, ErrOverflow)
+}
+
+func TestKey_Uint64Order(t *testing.T) {
+	a := assert.New(t)
+
+	rng := rand.New(rand.NewSource(3))
+
+	keys := make(Keys, 1000)
+	for i := range keys {
+		keys[i] = KeyAt(uint8(rng.Intn(3)), rng.Float64())
+	}
+	keys.Sort()
+
+	a.True(sort.SliceIsSorted(keys, func(i, j int) bool { return keys[i].Uint64() < keys[j].Uint64() }))
+}
+
+func TestIntKey_SQL(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	k := IntKey{mustKey("1|a")}
+	v, err := k.Value()
+	r.NoError(err)
+	a.Equal(int64(mustKey("1|a").Uint64()), v)
+
+	for _, src := range []any{v, uint64(v.(int64)), []byte("274877906944"), "274877906944"} {
+		var got IntKey
+		r.NoError(got.Scan(src))
+		a.Equal(uint8(1), got.bucket)
+	}
+
+	var got IntKey
+	r.NoError(got.Scan(v))
+	a.Equal("1|a", got.String())
+
+	a.Error(got.Scan(1.5))
+	a.Error(got.Scan("x"))
+	a.Error(got.Scan(int64(-1)))
+
+	_, err = IntKey{mustKey("1|a/1")}.Value()
+	a.ErrorIs(err, ErrSuffix)
+}